@@ -0,0 +1,240 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value is the set of types the generic accessors can convert key values to
+type Value interface {
+	string | int | int64 | uint | float64 | bool | time.Duration | IniContainer
+}
+
+// Get returns the value of the first key with the given name in the given section converted to T.
+// If the section or key does not exist, or the value cannot be converted, defaultValue is returned together with a *KeyError naming the section and key.
+//
+// Conversion rules:
+//
+//	int, int64, uint - from integers, from floats without a fractional part and from numeric strings.
+//
+//	float64 - from integers, floats and numeric strings.
+//
+//	bool - from bools, from the integers 0 and 1 and from the strings "true"/"false", "1"/"0", "yes"/"no" and "on"/"off" (case-insensitive).
+//
+//	string - from every value, formatted the same way as IniKey.ToValueString.
+//
+//	time.Duration - numbers are seconds, strings are either seconds or a duration like "1h30m".
+//
+//	IniContainer - from containers and from strings in container format.
+func Get[T Value](file *IniFile, sectionName string, keyName string, defaultValue T) (T, error) {
+	key, err := file.GetKeyFromSection(sectionName, keyName)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	value, err := Convert[T](key.Value)
+	if err != nil {
+		return defaultValue, newConvertError[T](sectionName, keyName, key.Value, err)
+	}
+	return value, nil
+}
+
+// GetAll returns the values of all keys with the given name in the given section converted to T, see Get for the conversion rules
+func GetAll[T Value](file *IniFile, sectionName string, keyName string) ([]T, error) {
+	keys, err := file.GetKeyFromSectionWithMultipleValues(sectionName, keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]T, 0, len(keys))
+	for i, key := range keys {
+		value, err := Convert[T](key.Value)
+		if err != nil {
+			return nil, newConvertError[T](sectionName, keyName, key.Value, fmt.Errorf("value %d: %w", i, err))
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Convert converts a key value to T, see Get for the conversion rules. The returned error wraps ErrTypeMismatch.
+func Convert[T Value](value interface{}) (T, error) {
+	var result T
+	var err error
+
+	switch out := any(&result).(type) {
+	case *string:
+		*out = formatValue(value)
+	case *int:
+		var i int64
+		i, err = toInt64(value)
+		if err == nil && (i > math.MaxInt || i < math.MinInt) {
+			err = errors.New("value overflows int")
+		}
+		*out = int(i)
+	case *int64:
+		*out, err = toInt64(value)
+	case *uint:
+		var i int64
+		i, err = toInt64(value)
+		if err == nil && i < 0 {
+			err = errors.New("value is negative")
+		}
+		*out = uint(i)
+	case *float64:
+		*out, err = toFloat64(value)
+	case *bool:
+		*out, err = toBool(value)
+	case *time.Duration:
+		*out, err = toDuration(value)
+	case *IniContainer:
+		*out, err = toContainer(value)
+	}
+
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%w: cannot convert %q to %T, %v", ErrTypeMismatch, formatValue(value), zero, err)
+	}
+	return result, nil
+}
+
+// newConvertError returns a KeyError for a value of the key that cannot be converted to T
+func newConvertError[T Value](sectionName string, keyName string, value interface{}, err error) *KeyError {
+	var zero T
+	expected := typeOfValue(zero)
+	if _, isDuration := any(zero).(time.Duration); isDuration || expected == Fail {
+		expected = KeyType(fmt.Sprintf("%T", zero))
+	}
+	return &KeyError{Section: sectionName, Key: keyName, Expected: expected, Actual: typeOfValue(value), Err: err}
+}
+
+//region Conversions
+
+// formatValue formats a key value the same way as IniKey.ToValueString
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case IniContainer:
+		return v.ToString()
+	case []ContainerKey:
+		return "(" + serializeToContainerKV(v) + ")"
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, errors.New("value overflows int64")
+		}
+		return int64(v), nil
+	case float64:
+		if hasDecimal(v) {
+			return 0, errors.New("value has a fractional part")
+		}
+		// float64(math.MaxInt64) is 2^63, which doesn't fit in an int64
+		if v >= math.MaxInt64 || v < math.MinInt64 {
+			return 0, errors.New("value overflows int64")
+		}
+		return int64(v), nil
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, errors.New("value is not a number")
+		}
+		return toInt64(f)
+	default:
+		return 0, errors.New("value is not a number")
+	}
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, errors.New("value is not a number")
+		}
+		return f, nil
+	default:
+		return 0, errors.New("value is not a number")
+	}
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int, int64, uint, float64:
+		f, _ := toFloat64(v)
+		if f == 0 {
+			return false, nil
+		} else if f == 1 {
+			return true, nil
+		}
+		return false, errors.New("only 0 and 1 can be converted to a bool")
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "yes", "on":
+			return true, nil
+		case "false", "0", "no", "off":
+			return false, nil
+		}
+		return false, errors.New("value is not a bool")
+	default:
+		return false, errors.New("value is not a bool")
+	}
+}
+
+func toDuration(value interface{}) (time.Duration, error) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+			return d, nil
+		}
+	}
+
+	seconds, err := toFloat64(value)
+	if err != nil {
+		return 0, errors.New("value is not a duration")
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func toContainer(value interface{}) (IniContainer, error) {
+	switch v := value.(type) {
+	case IniContainer:
+		return v, nil
+	case []ContainerKey:
+		return IniContainer{KeyValues: v}, nil
+	case string:
+		if checkValueType(v) != Container {
+			return IniContainer{}, errors.New("value is not a container")
+		}
+		return NewIniContainerFromString(v)
+	default:
+		return IniContainer{}, errors.New("value is not a container")
+	}
+}
+
+//endregion
@@ -0,0 +1,221 @@
+package ini
+
+import (
+	"math"
+	"strings"
+)
+
+//region Clone
+
+// Clone returns a deep copy of the file, the sections of the copy share the AllowedDuplicateKeys of the copy. Observers are not copied.
+func (f *IniFile) Clone() *IniFile {
+	file := NewIniFile(append([]string(nil), f.AllowedDuplicateKeys...)...)
+	file.lastOp = f.lastOp
+	for _, section := range f.Sections {
+		sectionCopy := section.cloneKeys()
+		sectionCopy.AllowedDuplicateKeys = &file.AllowedDuplicateKeys
+		sectionCopy.file = file
+		file.Sections = append(file.Sections, sectionCopy)
+	}
+	return file
+}
+
+// Clone returns a deep copy of the section, including its own copy of AllowedDuplicateKeys. The copy does not belong to any file.
+func (s *IniSection) Clone() *IniSection {
+	section := s.cloneKeys()
+	if s.AllowedDuplicateKeys != nil {
+		allowedDuplicateKeys := append([]string(nil), *s.AllowedDuplicateKeys...)
+		section.AllowedDuplicateKeys = &allowedDuplicateKeys
+	}
+	return section
+}
+
+// cloneKeys returns a copy of the section with deep copies of its keys, the copy shares the AllowedDuplicateKeys pointer of the section
+func (s *IniSection) cloneKeys() *IniSection {
+	section := NewIniSection(s.SectionName, s.AllowedDuplicateKeys)
+	for _, key := range s.Keys {
+		section.Keys = append(section.Keys, key.Clone())
+	}
+	return section
+}
+
+// Clone returns a deep copy of the key
+func (k *IniKey) Clone() *IniKey {
+	return &IniKey{Key: k.Key, Value: cloneValue(k.Value), Raw: k.Raw}
+}
+
+// Clone returns a deep copy of the container
+func (c *IniContainer) Clone() IniContainer {
+	return IniContainer{KeyValues: cloneContainerKeys(c.KeyValues)}
+}
+
+// Clone returns a deep copy of the container key
+func (c *ContainerKey) Clone() ContainerKey {
+	return ContainerKey{Key: c.Key, Value: cloneValue(c.Value)}
+}
+
+// cloneValue returns a deep copy of a key value
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case IniContainer:
+		return v.Clone()
+	case []ContainerKey:
+		return cloneContainerKeys(v)
+	default:
+		return value
+	}
+}
+
+func cloneContainerKeys(keyValues []ContainerKey) []ContainerKey {
+	if keyValues == nil {
+		return nil
+	}
+	result := make([]ContainerKey, len(keyValues))
+	for i := range keyValues {
+		result[i] = keyValues[i].Clone()
+	}
+	return result
+}
+
+//endregion
+
+//region Equal
+
+// EqualOptions controls how values are compared by the EqualWithOptions methods
+type EqualOptions struct {
+	// IgnoreOrder compares sections, keys and container fields regardless of their order
+	IgnoreOrder bool
+	// IgnoreCase compares section names, key names, container field names and string values case-insensitively
+	IgnoreCase bool
+	// NumericTolerance is the maximum difference between two numbers that are considered equal
+	NumericTolerance float64
+}
+
+// Equal returns true if both files have the same sections and keys in the same order, AllowedDuplicateKeys is not compared
+func (f *IniFile) Equal(other *IniFile) bool {
+	return f.EqualWithOptions(other, EqualOptions{})
+}
+
+// EqualWithOptions returns true if both files have the same sections and keys according to options, AllowedDuplicateKeys is not compared
+func (f *IniFile) EqualWithOptions(other *IniFile, options EqualOptions) bool {
+	if f == nil || other == nil {
+		return f == other
+	}
+	return equalSlices(f.Sections, other.Sections, options.IgnoreOrder, func(a *IniSection, b *IniSection) bool {
+		return a.EqualWithOptions(b, options)
+	})
+}
+
+// Equal returns true if both sections have the same name and keys in the same order, AllowedDuplicateKeys is not compared
+func (s *IniSection) Equal(other *IniSection) bool {
+	return s.EqualWithOptions(other, EqualOptions{})
+}
+
+// EqualWithOptions returns true if both sections have the same name and keys according to options, AllowedDuplicateKeys is not compared
+func (s *IniSection) EqualWithOptions(other *IniSection, options EqualOptions) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	if !options.namesEqual(s.SectionName, other.SectionName) {
+		return false
+	}
+	return equalSlices(s.Keys, other.Keys, options.IgnoreOrder, func(a *IniKey, b *IniKey) bool {
+		return a.EqualWithOptions(b, options)
+	})
+}
+
+// Equal returns true if both keys have the same name and value, Raw is not compared
+func (k *IniKey) Equal(other *IniKey) bool {
+	return k.EqualWithOptions(other, EqualOptions{})
+}
+
+// EqualWithOptions returns true if both keys have the same name and value according to options, Raw is not compared
+func (k *IniKey) EqualWithOptions(other *IniKey, options EqualOptions) bool {
+	if k == nil || other == nil {
+		return k == other
+	}
+	return options.namesEqual(k.Key, other.Key) && options.valuesEqual(k.Value, other.Value)
+}
+
+// Equal returns true if both containers have the same fields in the same order
+func (c *IniContainer) Equal(other IniContainer) bool {
+	return c.EqualWithOptions(other, EqualOptions{})
+}
+
+// EqualWithOptions returns true if both containers have the same fields according to options
+func (c *IniContainer) EqualWithOptions(other IniContainer, options EqualOptions) bool {
+	return options.containerKeysEqual(c.KeyValues, other.KeyValues)
+}
+
+func (o EqualOptions) namesEqual(a string, b string) bool {
+	if o.IgnoreCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func (o EqualOptions) containerKeysEqual(a []ContainerKey, b []ContainerKey) bool {
+	return equalSlices(a, b, o.IgnoreOrder, func(a ContainerKey, b ContainerKey) bool {
+		return o.namesEqual(a.Key, b.Key) && o.valuesEqual(a.Value, b.Value)
+	})
+}
+
+// valuesEqual compares two key values, numbers are compared by value regardless of being an int or a float64
+func (o EqualOptions) valuesEqual(a interface{}, b interface{}) bool {
+	if typeOfValue(a) == Container && typeOfValue(b) == Container {
+		containerA, _ := toContainer(a)
+		containerB, _ := toContainer(b)
+		return o.containerKeysEqual(containerA.KeyValues, containerB.KeyValues)
+	}
+
+	numberA, errA := toFloat64(a)
+	numberB, errB := toFloat64(b)
+	_, aIsString := a.(string)
+	_, bIsString := b.(string)
+	if errA == nil && errB == nil && !aIsString && !bIsString {
+		return math.Abs(numberA-numberB) <= o.NumericTolerance
+	}
+
+	if aIsString && bIsString {
+		return o.namesEqual(a.(string), b.(string))
+	}
+
+	if typeOfValue(a) != typeOfValue(b) {
+		return false
+	}
+	return formatValue(a) == formatValue(b)
+}
+
+// equalSlices returns true if a and b have equal elements, in the same order unless ignoreOrder is set
+func equalSlices[T any](a []T, b []T, ignoreOrder bool, equal func(a T, b T) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	if !ignoreOrder {
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	used := make([]bool, len(b))
+	for i := range a {
+		found := false
+		for j := range b {
+			if !used[j] && equal(a[i], b[j]) {
+				used[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+//endregion
@@ -0,0 +1,200 @@
+// Package cluster manages the configs of several servers, e.g. the maps of a cluster, that share most settings.
+// The cluster holds a shared GameUserSettings.ini and Game.ini and per server overrides that are applied on top of them.
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+const (
+	GameUserSettingsFile = "GameUserSettings.ini"
+	GameFile             = "Game.ini"
+)
+
+// ErrServerNotFound is returned when the cluster has no server with the name
+var ErrServerNotFound = errors.New("server not found")
+
+// Cluster holds the shared config and the servers of a cluster
+type Cluster struct {
+	GameUserSettings *ini.IniFile
+	Game             *ini.IniFile
+	Servers          []*Server
+}
+
+// Server holds the overrides of one server, every key in the overrides replaces all values of that key in the shared config
+type Server struct {
+	Name             string
+	GameUserSettings *ini.IniFile
+	Game             *ini.IniFile
+}
+
+// Config holds the generated files of a server
+type Config struct {
+	GameUserSettings *ini.IniFile
+	Game             *ini.IniFile
+}
+
+// Drift lists the differences between the file on disk and the generated file of a server
+type Drift struct {
+	Server string
+	// File is GameUserSettingsFile or GameFile
+	File string
+	// Missing is true if the file does not exist on disk
+	Missing bool
+	// Differences turn the generated file into the file on disk
+	Differences []ini.Difference
+}
+
+// New returns a cluster with empty shared files, the duplicate keys are allowed in all files of the cluster
+func New(allowedDuplicateKeys ...string) *Cluster {
+	return &Cluster{
+		GameUserSettings: ini.NewIniFile(allowedDuplicateKeys...),
+		Game:             ini.NewIniFile(allowedDuplicateKeys...),
+	}
+}
+
+// AddServer returns the server with the name, it is added with empty overrides if it doesn't exist
+func (c *Cluster) AddServer(name string) *Server {
+	if server, exists := c.Server(name); exists {
+		return server
+	}
+	server := &Server{
+		Name:             name,
+		GameUserSettings: ini.NewIniFile(c.GameUserSettings.AllowedDuplicateKeys...),
+		Game:             ini.NewIniFile(c.Game.AllowedDuplicateKeys...),
+	}
+	c.Servers = append(c.Servers, server)
+	return server
+}
+
+// Server returns the server with the name
+func (c *Cluster) Server(name string) (*Server, bool) {
+	for _, server := range c.Servers {
+		if server.Name == name {
+			return server, true
+		}
+	}
+	return nil, false
+}
+
+// RemoveServer removes the server with the name and returns true if it existed
+func (c *Cluster) RemoveServer(name string) bool {
+	for i, server := range c.Servers {
+		if server.Name == name {
+			c.Servers = append(c.Servers[:i], c.Servers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Generate returns the files of the server, the shared files are not changed
+func (c *Cluster) Generate(name string) (*Config, error) {
+	server, exists := c.Server(name)
+	if !exists {
+		return nil, fmt.Errorf("%w: %q", ErrServerNotFound, name)
+	}
+	return &Config{
+		GameUserSettings: merge(c.GameUserSettings, server.GameUserSettings),
+		Game:             merge(c.Game, server.Game),
+	}, nil
+}
+
+// Write generates the files of the server and saves them in configDir, e.g. ShooterGame/Saved/Config/WindowsServer
+func (c *Cluster) Write(name string, configDir string) error {
+	config, err := c.Generate(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return err
+	}
+	if err := ini.SaveFile(filepath.Join(configDir, GameUserSettingsFile), config.GameUserSettings); err != nil {
+		return err
+	}
+	return ini.SaveFile(filepath.Join(configDir, GameFile), config.Game)
+}
+
+// Drift compares the files of the server in configDir with the generated files, files without differences are not returned
+func (c *Cluster) Drift(name string, configDir string) ([]Drift, error) {
+	config, err := c.Generate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []Drift
+	for _, file := range []struct {
+		name      string
+		generated *ini.IniFile
+	}{
+		{GameUserSettingsFile, config.GameUserSettings},
+		{GameFile, config.Game},
+	} {
+		drift := Drift{Server: name, File: file.name}
+		actual, err := ini.LoadFile(filepath.Join(configDir, file.name), file.generated.AllowedDuplicateKeys...)
+		if errors.Is(err, os.ErrNotExist) {
+			drift.Missing = true
+			actual = ini.NewIniFile()
+		} else if err != nil {
+			return nil, err
+		}
+
+		matchNames(actual, file.generated)
+		drift.Differences = ini.Diff(file.generated, actual)
+		if drift.Missing || len(drift.Differences) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+// merge returns a copy of base with the keys of override. Sections and keys are matched ignoring case like the game does,
+// the values of a key take the places of the values in base in order and extra values are inserted after them.
+func merge(base *ini.IniFile, override *ini.IniFile) *ini.IniFile {
+	merged := base.Clone()
+	fields.AllowDuplicates(merged, override.AllowedDuplicateKeys...)
+
+	for _, overrideSection := range override.Sections {
+		section := fields.GetOrCreateSection(merged, overrideSection.SectionName)
+		done := make(map[string]bool)
+		for _, key := range overrideSection.Keys {
+			if done[strings.ToLower(key.Key)] {
+				continue
+			}
+			done[strings.ToLower(key.Key)] = true
+
+			var values []interface{}
+			for _, other := range overrideSection.Keys {
+				if strings.EqualFold(other.Key, key.Key) {
+					values = append(values, other.Clone().Value)
+				}
+			}
+			fields.ReplaceKeysIgnoringCase(section, key.Key, values)
+		}
+	}
+	return merged
+}
+
+// matchNames renames the sections and keys of file that match a section or key of reference ignoring case to the names in reference,
+// so files that only differ in case like the game reads them have no differences
+func matchNames(file *ini.IniFile, reference *ini.IniFile) {
+	for _, section := range file.Sections {
+		referenceSection, exists := fields.FindSection(reference, section.SectionName)
+		if !exists {
+			continue
+		}
+		section.SectionName = referenceSection.SectionName
+		for _, key := range section.Keys {
+			if referenceKey, exists := fields.FindKey(referenceSection, key.Key); exists {
+				key.Key = referenceKey.Key
+			}
+		}
+	}
+}
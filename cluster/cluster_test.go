@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func newCluster() *Cluster {
+	cluster := New("ConfigAddNPCSpawnEntriesContainer")
+	cluster.GameUserSettings.UpdateOrCreateKeyInSection("ServerSettings", "DifficultyOffset", 1)
+	cluster.GameUserSettings.UpdateOrCreateKeyInSection("ServerSettings", "XPMultiplier", 2)
+	cluster.GameUserSettings.UpdateOrCreateKeyInSection("SessionSettings", "Port", 7777)
+	cluster.Game.AddKeyToSection("/Script/ShooterGame.ShooterGameMode", "bAllowUnlimitedRespecs", true)
+
+	island := cluster.AddServer("TheIsland")
+	island.GameUserSettings.UpdateOrCreateKeyInSection("SessionSettings", "SessionName", "Cluster - The Island")
+
+	scorched := cluster.AddServer("ScorchedEarth")
+	scorched.GameUserSettings.UpdateOrCreateKeyInSection("SessionSettings", "Port", 7779)
+	scorched.Game.AddKeyToSection("/Script/ShooterGame.ShooterGameMode", "ConfigAddNPCSpawnEntriesContainer", `(NPCSpawnEntriesContainerClassString="DinoSpawnEntries_Desert_C")`)
+	scorched.Game.AddKeyToSection("/Script/ShooterGame.ShooterGameMode", "ConfigAddNPCSpawnEntriesContainer", `(NPCSpawnEntriesContainerClassString="DinoSpawnEntries_Dunes_C")`)
+	return cluster
+}
+
+func TestGenerate(t *testing.T) {
+	cluster := newCluster()
+	config, err := cluster.Generate("ScorchedEarth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[ServerSettings]
+DifficultyOffset=1
+XPMultiplier=2
+[SessionSettings]
+Port=7779
+`
+	if output := config.GameUserSettings.ToString(); output != expected {
+		t.Errorf("unexpected GameUserSettings.ini:\n%s", output)
+	}
+	expected = `[/Script/ShooterGame.ShooterGameMode]
+bAllowUnlimitedRespecs=true
+ConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntriesContainerClassString="DinoSpawnEntries_Desert_C")
+ConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntriesContainerClassString="DinoSpawnEntries_Dunes_C")
+`
+	if output := config.Game.ToString(); output != expected {
+		t.Errorf("unexpected Game.ini:\n%s", output)
+	}
+	if port, _ := cluster.GameUserSettings.GetKeyFromSection("SessionSettings", "Port"); port.Value != 7777 {
+		t.Error("the shared file was changed")
+	}
+
+	if _, err := cluster.Generate("Aberration"); !errors.Is(err, ErrServerNotFound) {
+		t.Errorf("expected ErrServerNotFound, got %v", err)
+	}
+}
+
+func TestGenerateIgnoresCase(t *testing.T) {
+	cluster := New("ConfigOverrideItemMaxQuantity")
+	cluster.Game, _ = ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=500))
+bAllowUnlimitedRespecs=True
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Wood_C",Quantity=(MaxItemQuantity=500))
+MaxTamedDinos=5000
+`, "ConfigOverrideItemMaxQuantity")
+
+	// The domain packages write the section name in lower case, like the game does after its first save
+	server := cluster.AddServer("Ragnarok")
+	server.Game.AddKeyToSection("/script/shootergame.shootergamemode", "configoverrideitemmaxquantity", `(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=1000))`)
+	server.Game.AddKeyToSection("/script/shootergame.shootergamemode", "bAllowUnlimitedRespecs", "True")
+
+	config, err := cluster.Generate("Ragnarok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+configoverrideitemmaxquantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=1000))
+bAllowUnlimitedRespecs=True
+MaxTamedDinos=5000
+`
+	if output := config.Game.ToString(); output != expected {
+		t.Errorf("unexpected Game.ini:\n%s", output)
+	}
+}
+
+func TestWriteAndDrift(t *testing.T) {
+	cluster := newCluster()
+	dir := filepath.Join(t.TempDir(), "TheIsland")
+
+	drifts, err := cluster.Drift("TheIsland", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 2 || !drifts[0].Missing || !drifts[1].Missing {
+		t.Fatalf("expected both files to be missing, got %+v", drifts)
+	}
+
+	if err := cluster.Write("TheIsland", dir); err != nil {
+		t.Fatal(err)
+	}
+	if drifts, _ := cluster.Drift("TheIsland", dir); len(drifts) != 0 {
+		t.Fatalf("expected no drift after writing, got %+v", drifts)
+	}
+
+	path := filepath.Join(dir, GameUserSettingsFile)
+	file, _ := ini.LoadFile(path)
+	file.UpdateOrCreateKeyInSection("ServerSettings", "XPMultiplier", 5)
+	if err := ini.SaveFile(path, file); err != nil {
+		t.Fatal(err)
+	}
+	drifts, _ = cluster.Drift("TheIsland", dir)
+	if len(drifts) != 1 || drifts[0].File != GameUserSettingsFile || len(drifts[0].Differences) != 1 {
+		t.Fatalf("expected one difference, got %+v", drifts)
+	}
+	difference := drifts[0].Differences[0]
+	if difference.Type != ini.KeySet || difference.Key != "XPMultiplier" || difference.OldValue != 2 || difference.NewValue != 5 {
+		t.Errorf("unexpected difference %+v", difference)
+	}
+
+	data := "[serversettings]\ndifficultyoffset=1\nxpmultiplier=2\n[SessionSettings]\nport=7777\nSessionName=Cluster - The Island\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if drifts, _ := cluster.Drift("TheIsland", dir); len(drifts) != 0 {
+		t.Errorf("expected no drift for names in another case, got %+v", drifts)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected only the two config files, got %d entries", len(entries))
+	}
+}
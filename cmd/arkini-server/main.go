@@ -0,0 +1,39 @@
+// Command arkini-server serves the ini files of a config directory over a local HTTP/JSON API, see the server package for the endpoints.
+//
+//	arkini-server -dir ShooterGame/Saved/Config/WindowsServer -addr 127.0.0.1:8080 -duplicate-keys OverridePlayerLevelEngramPoints,ConfigOverrideItemMaxQuantity
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JensvandeWiel/ark-ini/server"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory holding the ini files")
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	duplicateKeys := flag.String("duplicate-keys", "", "comma separated keys that may appear more than once in a section")
+	flag.Parse()
+
+	var allowedDuplicateKeys []string
+	for _, key := range strings.Split(*duplicateKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			allowedDuplicateKeys = append(allowedDuplicateKeys, key)
+		}
+	}
+
+	log.Printf("serving %s on http://%s", *dir, *addr)
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(*dir, allowedDuplicateKeys...),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	log.Fatal(httpServer.ListenAndServe())
+}
@@ -0,0 +1,375 @@
+// Package cmdline parses and generates the launch arguments of an ARK server e.g.
+// TheIsland_WP?listen?SessionName=Foo?MaxPlayers=70 -NoBattlEye -mods=123,456
+// and converts the settings between the command line and GameUserSettings.ini.
+package cmdline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+// ServerSettingsSection is the GameUserSettings.ini section most options belong to
+const ServerSettingsSection = "ServerSettings"
+
+// otherSections holds the options that belong to another section than ServerSettings
+var otherSections = []struct{ name, section string }{
+	{"SessionName", "SessionSettings"},
+	{"Port", "SessionSettings"},
+	{"QueryPort", "SessionSettings"},
+	{"MaxPlayers", "/Script/Engine.GameSession"},
+}
+
+// commandLineOnly holds the options that have no GameUserSettings.ini key, keys are lower case
+var commandLineOnly = map[string]bool{
+	"listen":               true,
+	"multihome":            true,
+	"altsavedirectoryname": true,
+}
+
+// flagKeys holds the flags that have a GameUserSettings.ini key, keys are lower case
+var flagKeys = map[string]string{
+	"mods": "ActiveMods",
+}
+
+// Argument is an option (?Name=Value) or a flag (-Name=Value), HasValue is false for arguments without a value like ?listen or -NoBattlEye
+type Argument struct {
+	Name     string
+	Value    string
+	HasValue bool
+}
+
+// CommandLine is the structured form of the launch arguments
+type CommandLine struct {
+	Map     string
+	Options []Argument
+	Flags   []Argument
+}
+
+// Conflict is a setting that has a different value on the command line than in the ini file
+type Conflict struct {
+	Name             string
+	Section          string
+	Key              string
+	CommandLineValue string
+	IniValue         string
+}
+
+//region Parsing and generating
+
+// Parse parses launch arguments, values can be quoted when they contain spaces e.g. ?SessionName="My Server"
+func Parse(commandLine string) (*CommandLine, error) {
+	tokens, err := tokenize(commandLine)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CommandLine{}
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "-") {
+			result.Flags = append(result.Flags, parseArgument(token[1:]))
+			continue
+		}
+		if i != 0 {
+			return nil, fmt.Errorf("unexpected argument %q, only the first argument can be the map", token)
+		}
+
+		parts := splitOutsideQuotes(token, '?')
+		result.Map = parts[0]
+		for _, part := range parts[1:] {
+			if part == "" {
+				continue
+			}
+			result.Options = append(result.Options, parseArgument(part))
+		}
+	}
+	return result, nil
+}
+
+// String returns the launch arguments, values containing spaces are quoted
+func (c *CommandLine) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(c.Map)
+	for _, option := range c.Options {
+		builder.WriteString("?" + option.String())
+	}
+	for _, flag := range c.Flags {
+		builder.WriteString(" -" + flag.String())
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// String returns the argument as Name=Value, or Name if it has no value
+func (a Argument) String() string {
+	if !a.HasValue {
+		return a.Name
+	}
+	if strings.ContainsAny(a.Value, " ?\t") {
+		return a.Name + "=" + strconv.Quote(a.Value)
+	}
+	return a.Name + "=" + a.Value
+}
+
+func parseArgument(text string) Argument {
+	name, value, hasValue := strings.Cut(text, "=")
+	return Argument{Name: name, Value: unquote(value), HasValue: hasValue}
+}
+
+func unquote(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+		return unquoted
+	}
+	return fields.Unquote(value)
+}
+
+// tokenize splits the command line on whitespace outside of double quotes
+func tokenize(commandLine string) ([]string, error) {
+	var tokens []string
+	current := strings.Builder{}
+	inQuotes := false
+	for _, r := range commandLine {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in %q", commandLine)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+func splitOutsideQuotes(text string, separator rune) []string {
+	var parts []string
+	start := 0
+	inQuotes := false
+	for i, r := range text {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == separator && !inQuotes {
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+//endregion
+
+//region Arguments
+
+// Option returns the option with the name ignoring case
+func (c *CommandLine) Option(name string) (Argument, bool) {
+	return find(c.Options, name)
+}
+
+// SetOption sets the value of the option, it is added if it doesn't exist
+func (c *CommandLine) SetOption(name string, value string) {
+	c.Options = set(c.Options, Argument{Name: name, Value: value, HasValue: true})
+}
+
+// RemoveOption removes the option and returns true if it existed
+func (c *CommandLine) RemoveOption(name string) bool {
+	var removed bool
+	c.Options, removed = remove(c.Options, name)
+	return removed
+}
+
+// Flag returns the flag with the name ignoring case
+func (c *CommandLine) Flag(name string) (Argument, bool) {
+	return find(c.Flags, name)
+}
+
+// SetFlag adds a flag without a value like -NoBattlEye if it doesn't exist
+func (c *CommandLine) SetFlag(name string) {
+	if _, exists := c.Flag(name); !exists {
+		c.Flags = append(c.Flags, Argument{Name: name})
+	}
+}
+
+// SetFlagValue sets the value of a flag like -mods=123,456, it is added if it doesn't exist
+func (c *CommandLine) SetFlagValue(name string, value string) {
+	c.Flags = set(c.Flags, Argument{Name: name, Value: value, HasValue: true})
+}
+
+// RemoveFlag removes the flag and returns true if it existed
+func (c *CommandLine) RemoveFlag(name string) bool {
+	var removed bool
+	c.Flags, removed = remove(c.Flags, name)
+	return removed
+}
+
+// Mods returns the mod ids of the -mods flag
+func (c *CommandLine) Mods() []string {
+	flag, exists := c.Flag("mods")
+	if !exists || flag.Value == "" {
+		return nil
+	}
+	var mods []string
+	for _, mod := range strings.Split(flag.Value, ",") {
+		if mod = strings.TrimSpace(mod); mod != "" {
+			mods = append(mods, mod)
+		}
+	}
+	return mods
+}
+
+func find(arguments []Argument, name string) (Argument, bool) {
+	for _, argument := range arguments {
+		if strings.EqualFold(argument.Name, name) {
+			return argument, true
+		}
+	}
+	return Argument{}, false
+}
+
+func set(arguments []Argument, argument Argument) []Argument {
+	for i := range arguments {
+		if strings.EqualFold(arguments[i].Name, argument.Name) {
+			argument.Name = arguments[i].Name
+			arguments[i] = argument
+			return arguments
+		}
+	}
+	return append(arguments, argument)
+}
+
+func remove(arguments []Argument, name string) ([]Argument, bool) {
+	for i := range arguments {
+		if strings.EqualFold(arguments[i].Name, name) {
+			return append(arguments[:i], arguments[i+1:]...), true
+		}
+	}
+	return arguments, false
+}
+
+//endregion
+
+//region Ini conversion
+
+// SectionOf returns the GameUserSettings.ini section of the option, or false if the option can only be set on the command line
+func SectionOf(name string) (string, bool) {
+	if commandLineOnly[strings.ToLower(name)] {
+		return "", false
+	}
+	for _, other := range otherSections {
+		if strings.EqualFold(other.name, name) {
+			return other.section, true
+		}
+	}
+	return ServerSettingsSection, true
+}
+
+// Apply writes the options and flags that have a GameUserSettings.ini key to the file, existing keys are matched ignoring case and updated where they are
+func (c *CommandLine) Apply(file *ini.IniFile) {
+	for _, option := range c.Options {
+		sectionName, exists := SectionOf(option.Name)
+		if !exists || !option.HasValue {
+			continue
+		}
+		setKey(fields.GetOrCreateSection(file, sectionName), option.Name, option.Value)
+	}
+	for _, flag := range c.Flags {
+		keyName, exists := flagKeys[strings.ToLower(flag.Name)]
+		if !exists || !flag.HasValue {
+			continue
+		}
+		setKey(fields.GetOrCreateSection(file, ServerSettingsSection), keyName, flag.Value)
+	}
+}
+
+// FromIniFile returns a command line for the map with every key of the ServerSettings section and the other known sections as an option,
+// ActiveMods is written as the -mods flag
+func FromIniFile(mapName string, file *ini.IniFile) *CommandLine {
+	commandLine := &CommandLine{Map: mapName}
+	if section, exists := fields.FindSection(file, ServerSettingsSection); exists {
+		for _, key := range section.Keys {
+			if strings.EqualFold(key.Key, flagKeys["mods"]) {
+				commandLine.SetFlagValue("mods", key.ToValueString())
+				continue
+			}
+			commandLine.SetOption(key.Key, fields.Unquote(key.ToValueString()))
+		}
+	}
+
+	for _, other := range otherSections {
+		section, exists := fields.FindSection(file, other.section)
+		if !exists {
+			continue
+		}
+		if key, exists := fields.FindKey(section, other.name); exists {
+			commandLine.SetOption(key.Key, fields.Unquote(key.ToValueString()))
+		}
+	}
+	return commandLine
+}
+
+// Conflicts returns the settings that are set on the command line and in the file with a different value,
+// numbers and booleans are compared by value e.g. 1 and 1.0 or True and true are the same, lists may have spaces after the commas
+func (c *CommandLine) Conflicts(file *ini.IniFile) []Conflict {
+	var conflicts []Conflict
+	check := func(name string, sectionName string, keyName string, value string) {
+		section, exists := fields.FindSection(file, sectionName)
+		if !exists {
+			return
+		}
+		key, exists := fields.FindKey(section, keyName)
+		if !exists {
+			return
+		}
+		iniValue := fields.Unquote(key.ToValueString())
+		if !sameValue(value, iniValue) {
+			conflicts = append(conflicts, Conflict{Name: name, Section: section.SectionName, Key: key.Key, CommandLineValue: value, IniValue: iniValue})
+		}
+	}
+
+	for _, option := range c.Options {
+		if sectionName, exists := SectionOf(option.Name); exists && option.HasValue {
+			check(option.Name, sectionName, option.Name, option.Value)
+		}
+	}
+	for _, flag := range c.Flags {
+		if keyName, exists := flagKeys[strings.ToLower(flag.Name)]; exists && flag.HasValue {
+			check(flag.Name, ServerSettingsSection, keyName, flag.Value)
+		}
+	}
+	return conflicts
+}
+
+func sameValue(a string, b string) bool {
+	if a == b {
+		return true
+	}
+	if aFloat, err := strconv.ParseFloat(a, 64); err == nil {
+		bFloat, err := strconv.ParseFloat(b, 64)
+		return err == nil && aFloat == bFloat
+	}
+	if aBool, err := strconv.ParseBool(strings.ToLower(a)); err == nil {
+		bBool, err := strconv.ParseBool(strings.ToLower(b))
+		return err == nil && aBool == bBool
+	}
+	return strings.ReplaceAll(a, ", ", ",") == strings.ReplaceAll(b, ", ", ",")
+}
+
+func setKey(section *ini.IniSection, keyName string, value string) {
+	if key, exists := fields.FindKey(section, keyName); exists {
+		keyName = key.Key
+	}
+	section.AddOrReplaceKey(keyName, value)
+}
+
+//endregion
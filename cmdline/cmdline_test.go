@@ -0,0 +1,91 @@
+package cmdline
+
+import (
+	"reflect"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestParseAndString(t *testing.T) {
+	commandLine, err := Parse(`TheIsland_WP?listen?SessionName="My Server"?MaxPlayers=70 -NoBattlEye -mods=123,456`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &CommandLine{
+		Map: "TheIsland_WP",
+		Options: []Argument{
+			{Name: "listen"},
+			{Name: "SessionName", Value: "My Server", HasValue: true},
+			{Name: "MaxPlayers", Value: "70", HasValue: true},
+		},
+		Flags: []Argument{
+			{Name: "NoBattlEye"},
+			{Name: "mods", Value: "123,456", HasValue: true},
+		},
+	}
+	if !reflect.DeepEqual(commandLine, expected) {
+		t.Fatalf("unexpected command line %+v", commandLine)
+	}
+	if mods := commandLine.Mods(); !reflect.DeepEqual(mods, []string{"123", "456"}) {
+		t.Errorf("unexpected mods %v", mods)
+	}
+
+	commandLine.SetOption("maxplayers", "50")
+	commandLine.SetOption("ServerPVE", "true")
+	commandLine.RemoveOption("listen")
+	commandLine.SetFlag("NoBattlEye")
+	commandLine.SetFlag("crossplay")
+	expectedString := `TheIsland_WP?SessionName="My Server"?MaxPlayers=50?ServerPVE=true -NoBattlEye -mods=123,456 -crossplay`
+	if output := commandLine.String(); output != expectedString {
+		t.Errorf("unexpected command line %s", output)
+	}
+
+	if _, err := Parse(`TheIsland_WP?SessionName="Broken`); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+	if _, err := Parse(`TheIsland_WP ScorchedEarth_WP`); err == nil {
+		t.Error("expected an error for a second map")
+	}
+}
+
+func TestIniConversion(t *testing.T) {
+	file, _ := ini.DeserializeIniFile(`[ServerSettings]
+ServerPVE=True
+DifficultyOffset=1.0
+ActiveMods=123, 456
+[SessionSettings]
+SessionName=Old Name
+`)
+	commandLine, _ := Parse(`TheIsland_WP?listen?SessionName=Foo?MaxPlayers=70?ServerPVE=true?DifficultyOffset=1?XPMultiplier=2 -NoBattlEye -mods=123,456`)
+
+	conflicts := commandLine.Conflicts(file)
+	expected := []Conflict{{Name: "SessionName", Section: "SessionSettings", Key: "SessionName", CommandLineValue: "Foo", IniValue: "Old Name"}}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("unexpected conflicts %+v", conflicts)
+	}
+
+	commandLine.Apply(file)
+	if conflicts := commandLine.Conflicts(file); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts after applying, got %+v", conflicts)
+	}
+	expectedIni := `[ServerSettings]
+ServerPVE=true
+DifficultyOffset=1
+ActiveMods=123,456
+XPMultiplier=2
+[SessionSettings]
+SessionName=Foo
+[/Script/Engine.GameSession]
+MaxPlayers=70
+`
+	if output := file.ToString(); output != expectedIni {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+
+	generated := FromIniFile("TheIsland_WP", file)
+	if output := generated.String(); output != "TheIsland_WP?ServerPVE=true?DifficultyOffset=1?XPMultiplier=2?SessionName=Foo?MaxPlayers=70 -mods=123,456" {
+		t.Errorf("unexpected generated command line %s", output)
+	}
+}
@@ -1,7 +1,6 @@
 package ini
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 )
@@ -14,7 +13,7 @@ type IniContainer struct {
 
 // NewIniContainerFromString returns a new IniContainer. The input string must be '
 func NewIniContainerFromString(inputString string) (IniContainer, error) {
-	keyValues, err := deserializeToContainerKv(inputString)
+	keyValues, err := deserializeToContainerKv(inputString, valueParser{})
 	if err != nil {
 		return IniContainer{}, err
 	}
@@ -22,20 +21,167 @@ func NewIniContainerFromString(inputString string) (IniContainer, error) {
 	return IniContainer{KeyValues: keyValues}, nil
 }
 
+// NewIniContainerFromSlice returns a new IniContainer holding inputSlice, nested []ContainerKey values are converted to IniContainer
 func NewIniContainerFromSlice(inputSlice []ContainerKey) IniContainer {
-	return IniContainer{KeyValues: inputSlice}
+	container := IniContainer{KeyValues: inputSlice}
+	container.Normalize()
+	return container
 }
 
-// FindKey returns the key with the given name and true, or nil and false if it doesn't exist
+// Normalize converts nested []ContainerKey values to IniContainer at every depth.
+// Nested containers used to be stored as []ContainerKey, call this on containers built by hand in that form so they behave like parsed ones.
+func (c *IniContainer) Normalize() {
+	for i := range c.KeyValues {
+		c.KeyValues[i].Value = normalizeValue(c.KeyValues[i].Value)
+	}
+}
+
+// normalizeValue returns value with all nested []ContainerKey values converted to IniContainer
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []ContainerKey:
+		container := IniContainer{KeyValues: v}
+		container.Normalize()
+		return container
+	case IniContainer:
+		v.Normalize()
+		return v
+	default:
+		return value
+	}
+}
+
+// FindKey returns the key with the given name and true, or nil and false if it doesn't exist. The key points into the container so changes to it are kept.
 func (c *IniContainer) FindKey(keyName string) (*ContainerKey, bool) {
-	for _, key := range c.KeyValues {
-		if key.Key == keyName {
-			return &key, true
+	for i := range c.KeyValues {
+		if c.KeyValues[i].Key == keyName {
+			return &c.KeyValues[i], true
+		}
+	}
+	return nil, false
+}
+
+//region Editing
+
+// Get returns the field at the dotted path (e.g. "Quantity.MaxItemQuantity") and true, or nil and false if it doesn't exist. The field points into the container so changes to it are kept.
+func (c *IniContainer) Get(path string) (*ContainerKey, bool) {
+	keyValues := c.KeyValues
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		index := indexOfContainerKey(keyValues, name)
+		if index < 0 {
+			return nil, false
+		}
+		if i == len(names)-1 {
+			return &keyValues[index], true
+		}
+
+		var ok bool
+		if keyValues, ok = containerKeysOf(keyValues[index].Value); !ok {
+			return nil, false
 		}
 	}
 	return nil, false
 }
 
+// Set sets the value of the field at the dotted path, missing fields are appended and missing parent containers are created
+func (c *IniContainer) Set(path string, value interface{}) error {
+	return c.edit(path, true, func(keyValues []ContainerKey, name string) ([]ContainerKey, error) {
+		if index := indexOfContainerKey(keyValues, name); index >= 0 {
+			keyValues[index].Value = value
+			return keyValues, nil
+		}
+		return append(keyValues, ContainerKey{Key: name, Value: value}), nil
+	})
+}
+
+// Insert inserts a field at index in the container holding the dotted path, the last name of the path is the name of the new field (e.g. "Quantity.MaxItemQuantity" inserts MaxItemQuantity in Quantity)
+func (c *IniContainer) Insert(path string, index int, value interface{}) error {
+	return c.edit(path, false, func(keyValues []ContainerKey, name string) ([]ContainerKey, error) {
+		if index < 0 || index > len(keyValues) {
+			return nil, &KeyError{Key: path, Err: fmt.Errorf("index %d out of range", index)}
+		}
+		return append(keyValues[:index], append([]ContainerKey{{Key: name, Value: value}}, keyValues[index:]...)...), nil
+	})
+}
+
+// Remove removes the field at the dotted path, it returns an error wrapping ErrKeyNotFound if the field doesn't exist
+func (c *IniContainer) Remove(path string) error {
+	return c.edit(path, false, func(keyValues []ContainerKey, name string) ([]ContainerKey, error) {
+		index := indexOfContainerKey(keyValues, name)
+		if index < 0 {
+			return nil, &KeyError{Key: path, Err: ErrKeyNotFound}
+		}
+		return append(keyValues[:index], keyValues[index+1:]...), nil
+	})
+}
+
+// Rename renames the field at the dotted path to newName, the field keeps its position
+func (c *IniContainer) Rename(path string, newName string) error {
+	return c.edit(path, false, func(keyValues []ContainerKey, name string) ([]ContainerKey, error) {
+		index := indexOfContainerKey(keyValues, name)
+		if index < 0 {
+			return nil, &KeyError{Key: path, Err: ErrKeyNotFound}
+		}
+		keyValues[index].Key = newName
+		return keyValues, nil
+	})
+}
+
+// edit calls fn with the fields of the container holding the dotted path and the last name of the path, the fields returned by fn replace the old ones.
+// Nested containers keep their representation (IniContainer or []ContainerKey).
+func (c *IniContainer) edit(path string, create bool, fn func(keyValues []ContainerKey, name string) ([]ContainerKey, error)) error {
+	keyValues, err := editContainerKeys(c.KeyValues, path, strings.Split(path, "."), create, fn)
+	if err != nil {
+		return err
+	}
+	c.KeyValues = keyValues
+	return nil
+}
+
+func editContainerKeys(keyValues []ContainerKey, path string, names []string, create bool, fn func(keyValues []ContainerKey, name string) ([]ContainerKey, error)) ([]ContainerKey, error) {
+	if len(names) == 1 {
+		return fn(keyValues, names[0])
+	}
+
+	index := indexOfContainerKey(keyValues, names[0])
+	if index < 0 {
+		if !create {
+			return nil, &KeyError{Key: path, Err: ErrKeyNotFound}
+		}
+		keyValues = append(keyValues, ContainerKey{Key: names[0], Value: IniContainer{}})
+		index = len(keyValues) - 1
+	}
+
+	nested, ok := containerKeysOf(keyValues[index].Value)
+	if !ok {
+		return nil, newTypeError(path, Container, keyValues[index].Value)
+	}
+	nested, err := editContainerKeys(nested, path, names[1:], create, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isSlice := keyValues[index].Value.([]ContainerKey); isSlice {
+		keyValues[index].Value = nested
+	} else {
+		keyValues[index].Value = IniContainer{KeyValues: nested}
+	}
+	return keyValues, nil
+}
+
+// indexOfContainerKey returns the index of the first field with the given name, or -1 if it doesn't exist
+func indexOfContainerKey(keyValues []ContainerKey, name string) int {
+	for i := range keyValues {
+		if keyValues[i].Key == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//endregion
+
 //endregion
 
 //region ContainerKey
@@ -46,16 +192,15 @@ type ContainerKey struct {
 }
 
 func (c *ContainerKey) ToString() string {
-	return fmt.Sprintf("%s=%v", c.Key, c.Value)
+	if c.Key == "" {
+		return c.ToValueString()
+	}
+	return c.Key + "=" + c.ToValueString()
 }
 
 // ToValueString returns the key's value as a string
 func (c *ContainerKey) ToValueString() string {
-	if container, ok := c.Value.(IniContainer); ok {
-		return container.ToString()
-	} else {
-		return fmt.Sprintf("%v", c.Value)
-	}
+	return formatValue(c.Value)
 }
 
 //region Key Conversions
@@ -67,7 +212,7 @@ func (c *ContainerKey) AsString() (string, error) {
 		return value, nil
 
 	} else {
-		return "", errors.New("key value is not a string")
+		return "", newTypeError(c.Key, String, c.Value)
 	}
 }
 
@@ -76,7 +221,7 @@ func (c *ContainerKey) AsInt() (int, error) {
 	if value, ok := c.Value.(int); ok {
 		return value, nil
 	} else {
-		return -1, errors.New("key value is not an int")
+		return -1, newTypeError(c.Key, Int, c.Value)
 	}
 }
 
@@ -85,7 +230,7 @@ func (c *ContainerKey) AsFloat64() (float64, error) {
 	if value, ok := c.Value.(float64); ok {
 		return value, nil
 	} else {
-		return -1, errors.New("key value is not a float64")
+		return -1, newTypeError(c.Key, Float64, c.Value)
 	}
 }
 
@@ -94,21 +239,41 @@ func (c *ContainerKey) AsBool() (bool, error) {
 	if value, ok := c.Value.(bool); ok {
 		return value, nil
 	} else {
-		return false, errors.New("key value is not a bool")
+		return false, newTypeError(c.Key, Boolean, c.Value)
 	}
 }
 
-// AsContainer returns the key value as a container
+// AsContainer returns the key value as a container, a []ContainerKey value is returned as an IniContainer holding it
 func (c *ContainerKey) AsContainer() (IniContainer, error) {
 	if container, ok := c.Value.(IniContainer); ok {
 		return container, nil
 	} else if container, ok := c.Value.([]ContainerKey); ok {
 		return IniContainer{KeyValues: container}, nil
 	} else {
-		return IniContainer{}, errors.New("key value is not a container")
+		return IniContainer{}, newTypeError(c.Key, Container, c.Value)
 	}
 }
 
+// EditContainer calls fn with the nested container value of the field and stores the edited container back in the field
+func (c *ContainerKey) EditContainer(fn func(container *IniContainer) error) error {
+	container, err := c.AsContainer()
+	if err != nil {
+		return err
+	}
+	// fn edits a copy, the fields of a container share their backing array so an error would leave the field half edited
+	container = container.Clone()
+	if err := fn(&container); err != nil {
+		return err
+	}
+
+	if _, isSlice := c.Value.([]ContainerKey); isSlice {
+		c.Value = container.KeyValues
+	} else {
+		c.Value = container
+	}
+	return nil
+}
+
 // AsGuessedValue returns the key value as a guessed value and the value type
 func (c *ContainerKey) AsGuessedValue() (interface{}, KeyType, error) {
 
@@ -126,7 +291,7 @@ func (c *ContainerKey) AsGuessedValue() (interface{}, KeyType, error) {
 	case []ContainerKey:
 		return IniContainer{KeyValues: c.Value.([]ContainerKey)}, Container, nil
 	default:
-		return nil, Fail, errors.New("unknown key type")
+		return nil, Fail, &KeyError{Key: c.Key, Err: fmt.Errorf("%w: unknown key type %T", ErrTypeMismatch, c.Value)}
 	}
 }
 
@@ -146,85 +311,86 @@ func (c *IniContainer) ToString() string {
 	return "(" + serializeToContainerKV(c.KeyValues) + ")"
 }
 
-// serializeToContainerKV serializes a slice of key-value pairs to a string
+// serializeToContainerKV serializes a slice of key-value pairs to a string, fields without a name are written as their value only
 func serializeToContainerKV(inputSlice []ContainerKey) string {
 	var parts []string
 
 	for _, kv := range inputSlice {
-		if nestedSlice, ok := kv.Value.([]ContainerKey); ok {
-			// Recursively serialize nested slices
-			nestedStr := serializeToContainerKV(nestedSlice)
-			parts = append(parts, fmt.Sprintf("%s=(%s)", kv.Key, nestedStr))
+		if kv.Key == "" {
+			parts = append(parts, formatValue(kv.Value))
 		} else {
-			// serializeToContainerKV simple values
-			parts = append(parts, fmt.Sprintf("%s=%v", kv.Key, kv.Value))
+			parts = append(parts, kv.Key+"="+formatValue(kv.Value))
 		}
 	}
 
 	return strings.Join(parts, ",")
 }
 
-// deserializeToContainerKv deserializes a string to a slice of key-value pairs
-func deserializeToContainerKv(inputString string) ([]ContainerKey, error) {
+// deserializeToContainerKv deserializes a string to a slice of key-value pairs, the values are parsed by parser.
+// Nested containers become IniContainer values, list elements without a name (e.g. the items of `("A","B")`) become fields with an empty Key.
+func deserializeToContainerKv(inputString string, parser valueParser) ([]ContainerKey, error) {
 	var result []ContainerKey
 
-	if inputString == "" || strings.TrimSpace(inputString) == "" {
-		return nil, errors.New("inputString is empty")
+	inputString = strings.TrimSpace(inputString)
+	if inputString == "" {
+		return nil, ErrEmptyInput
 	}
 
-	var parts []string
-
-	if strings.HasPrefix(inputString, "(") && strings.HasSuffix(inputString, ")") {
+	if isWrappedInParentheses(inputString) {
 		//it is only the value of a "container" key
-		inputString = strings.Trim(inputString, "()")
-
-		parts = splitInputString(inputString)
-
-	} else {
-		parts = splitInputString(inputString)
+		inputString = inputString[1 : len(inputString)-1]
 	}
 
 	// Process each part to build the result
-	for _, part := range parts {
-		kv := strings.SplitN(part, "=", 2)
-		key := strings.TrimSpace(kv[0])
-
-		if strings.Contains(kv[1], "(") {
-			// If the value contains '(', it means it's a nested structure
-			nestedStr := strings.Trim(kv[1], "()")
-			nestedSlice, err := deserializeToContainerKv(nestedStr)
-			if err != nil {
-				return nil, err
+	for _, part := range splitInputString(inputString) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		separator := indexOutsideParentheses(part, '=')
+		if separator < 0 {
+			// A list element without a name, unbalanced parentheses like "(A=(B=1)" can't be split further and are kept as a string
+			if !isWrappedInParentheses(part) && checkValueType(part) == Container {
+				result = append(result, ContainerKey{Key: "", Value: part})
+				continue
 			}
-			result = append(result, ContainerKey{Key: key, Value: nestedSlice})
-		} else {
-			// If the value is a simple value, store it directly
-			result = append(result, ContainerKey{Key: key, Value: toGuessedType(strings.TrimSpace(kv[1]))})
+			result = append(result, ContainerKey{Key: "", Value: parser.guess(part)})
+			continue
 		}
+
+		key := strings.TrimSpace(part[:separator])
+		value, err := parser.parseField(key, strings.TrimSpace(part[separator+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", key, err)
+		}
+		result = append(result, ContainerKey{Key: key, Value: value})
 	}
 
 	return result, nil
 }
 
-// splitInputString splits the input string based on parentheses and commas
+// splitInputString splits the input string on the commas that are not inside parentheses or quotes
 func splitInputString(inputString string) []string {
 	var parts []string
 	var currentPart strings.Builder
 	openParentheses := 0
+	inQuotes := false
 
 	for _, char := range inputString {
-		switch char {
-		case '(':
+		switch {
+		case char == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case char == '(':
 			openParentheses++
-		case ')':
+		case char == ')':
 			openParentheses--
-		case ',':
-			if openParentheses == 0 {
-				// Split only if not inside parentheses
-				parts = append(parts, currentPart.String())
-				currentPart.Reset()
-				continue
-			}
+		case char == ',' && openParentheses == 0:
+			// Split only if not inside parentheses
+			parts = append(parts, currentPart.String())
+			currentPart.Reset()
+			continue
 		}
 
 		currentPart.WriteRune(char)
@@ -238,4 +404,32 @@ func splitInputString(inputString string) []string {
 	return parts
 }
 
+// indexOutsideParentheses returns the index of the first target character that is not inside parentheses or quotes, or -1
+func indexOutsideParentheses(input string, target rune) int {
+	openParentheses := 0
+	inQuotes := false
+	for i, char := range input {
+		switch {
+		case inQuotes && char != '"':
+		case char == target && openParentheses == 0:
+			return i
+		case char == '"':
+			inQuotes = !inQuotes
+		case char == '(':
+			openParentheses++
+		case char == ')':
+			openParentheses--
+		}
+	}
+	return -1
+}
+
+// isWrappedInParentheses returns true if the input starts with an opening parenthesis that is closed by its last character, e.g. "(a=1)" but not "(a=1),(b=2)"
+func isWrappedInParentheses(input string) bool {
+	if !strings.HasPrefix(input, "(") || !strings.HasSuffix(input, ")") {
+		return false
+	}
+	return indexOutsideParentheses(input[1:], ')') == len(input)-2
+}
+
 //endregion
@@ -0,0 +1,72 @@
+package ini
+
+// Difference is a difference between two files, it uses the same change types as ChangeEvent
+type Difference struct {
+	// Type is SectionAdded, SectionRemoved, KeyAdded, KeySet or KeyRemoved
+	Type    ChangeType
+	Section string
+	// Key is empty for section differences
+	Key string
+	// Index is the position of the key among the keys with the same name in the section
+	Index    int
+	OldValue interface{}
+	NewValue interface{}
+}
+
+// Diff returns the differences that turn old into new. Sections and keys are matched by name, keys with the same name are compared in order,
+// values are compared by their string form so e.g. 1 and "1" are the same. The keys of added and removed sections are not listed.
+func Diff(old *IniFile, new *IniFile) []Difference {
+	var differences []Difference
+	for _, oldSection := range old.Sections {
+		newSection, exists := new.GetSection(oldSection.SectionName)
+		if !exists {
+			differences = append(differences, Difference{Type: SectionRemoved, Section: oldSection.SectionName})
+			continue
+		}
+		differences = append(differences, diffSections(oldSection, newSection)...)
+	}
+	for _, newSection := range new.Sections {
+		if _, exists := old.GetSection(newSection.SectionName); !exists {
+			differences = append(differences, Difference{Type: SectionAdded, Section: newSection.SectionName})
+		}
+	}
+	return differences
+}
+
+func diffSections(old *IniSection, new *IniSection) []Difference {
+	var differences []Difference
+	var names []string
+	seen := make(map[string]bool)
+	for _, keys := range [][]*IniKey{old.Keys, new.Keys} {
+		for _, key := range keys {
+			if !seen[key.Key] {
+				seen[key.Key] = true
+				names = append(names, key.Key)
+			}
+		}
+	}
+
+	for _, name := range names {
+		oldKeys := old.GetMultipleKeys(name)
+		newKeys := new.GetMultipleKeys(name)
+		for i := 0; i < len(oldKeys) || i < len(newKeys); i++ {
+			difference := Difference{Section: new.SectionName, Key: name, Index: i}
+			switch {
+			case i >= len(newKeys):
+				difference.Type = KeyRemoved
+				difference.OldValue = oldKeys[i].Value
+			case i >= len(oldKeys):
+				difference.Type = KeyAdded
+				difference.NewValue = newKeys[i].Value
+			case formatValue(oldKeys[i].Value) != formatValue(newKeys[i].Value):
+				difference.Type = KeySet
+				difference.OldValue = oldKeys[i].Value
+				difference.NewValue = newKeys[i].Value
+			default:
+				continue
+			}
+			differences = append(differences, difference)
+		}
+	}
+	return differences
+}
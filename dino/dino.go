@@ -0,0 +1,428 @@
+// Package dino reads and edits the per dino overrides of a Game.ini file: DinoSpawnWeightMultipliers, NPCReplacements,
+// DinoClassDamageMultipliers, TamedDinoClassResistanceMultipliers and PreventDinoTameClassNames.
+package dino
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+const (
+	SpawnWeightsKey          = "DinoSpawnWeightMultipliers"
+	ReplacementsKey          = "NPCReplacements"
+	DamageMultipliersKey     = "DinoClassDamageMultipliers"
+	ResistanceMultipliersKey = "TamedDinoClassResistanceMultipliers"
+	PreventTameKey           = "PreventDinoTameClassNames"
+)
+
+// Keys lists the dino keys, the game reads one entry per dino from each of them so the parser has to keep every line
+var Keys = []string{SpawnWeightsKey, ReplacementsKey, DamageMultipliersKey, ResistanceMultipliersKey, PreventTameKey}
+
+// Dino holds all overrides of one dino, fields that are nil are not written and keep the default of the game.
+// Spawn weights are keyed by NameTag and the other overrides by ClassName, both are known once a Catalogue is merged.
+type Dino struct {
+	// ClassName is the class of the dino e.g. Rex_Character_BP_C
+	ClassName string
+	// NameTag is the DinoNameTag of the dino e.g. Rex
+	NameTag string
+
+	SpawnWeightMultiplier        *float64
+	OverrideSpawnLimitPercentage *bool
+	SpawnLimitPercentage         *float64
+	// ReplaceWith is the ToClassName of the NPCReplacements entry, an empty string stops the dino from spawning
+	ReplaceWith *string
+	// DamageMultiplier is the DinoClassDamageMultipliers entry of the dino
+	DamageMultiplier *float64
+	// TamedResistanceMultiplier is the TamedDinoClassResistanceMultipliers entry of the dino
+	TamedResistanceMultiplier *float64
+	// PreventTaming is true if the dino is listed in PreventDinoTameClassNames
+	PreventTaming bool
+}
+
+// Overrides holds the overrides of all dinos in the order they first appear
+type Overrides struct {
+	Dinos []*Dino
+}
+
+// Reference is an override referring to a class or name tag that is not in the catalogue
+type Reference struct {
+	Key  string
+	Name string
+}
+
+// Conflict is a spawn weight set by name tag that Merge could not join with a class of the tag, because the class already has a different spawn weight
+type Conflict struct {
+	NameTag   string
+	ClassName string
+}
+
+// ErrEmptyName is returned when a dino is looked up or read without a class name or name tag
+var ErrEmptyName = errors.New("empty dino class name or name tag")
+
+//region Loading and saving
+
+// Load reads all dino overrides from the file
+func Load(file *ini.IniFile) (*Overrides, error) {
+	section, exists := fields.FindSection(file, fields.GameModeSection)
+	if !exists {
+		return &Overrides{}, nil
+	}
+	return FromSection(section)
+}
+
+// Save replaces all dino overrides in the file with the overrides, the keys are added to the allowed duplicate keys of the file
+func (o *Overrides) Save(file *ini.IniFile) {
+	fields.AllowDuplicates(file, Keys...)
+	o.ToSection(fields.GetOrCreateSection(file, fields.GameModeSection))
+}
+
+// FromSection reads all dino overrides from the section, the key names are matched ignoring case
+func FromSection(section *ini.IniSection) (*Overrides, error) {
+	overrides := &Overrides{}
+	for i, key := range section.Keys {
+		switch keyName := fields.KeyName(key.Key, Keys...); keyName {
+		case SpawnWeightsKey, ReplacementsKey, DamageMultipliersKey, ResistanceMultipliersKey:
+			container, err := key.AsContainer()
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+			if err := overrides.read(keyName, container); err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+		case PreventTameKey:
+			dino, err := overrides.Class(fields.Unquote(key.ToValueString()))
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+			dino.PreventTaming = true
+		}
+	}
+	return overrides, nil
+}
+
+// ToSection replaces all dino overrides in the section with the overrides
+func (o *Overrides) ToSection(section *ini.IniSection) {
+	var spawnWeights, replacements, damage, resistance []ini.IniContainer
+	writtenTags := make(map[string]bool)
+	for _, dino := range o.Dinos {
+		if dino.SpawnWeightMultiplier != nil && dino.NameTag != "" && !writtenTags[strings.ToLower(dino.NameTag)] {
+			writtenTags[strings.ToLower(dino.NameTag)] = true
+			builder := &fields.Builder{}
+			builder.Add("DinoNameTag", dino.NameTag).Add("SpawnWeightMultiplier", *dino.SpawnWeightMultiplier)
+			fields.AddOptional(builder, "OverrideSpawnLimitPercentage", dino.OverrideSpawnLimitPercentage)
+			fields.AddOptional(builder, "SpawnLimitPercentage", dino.SpawnLimitPercentage)
+			spawnWeights = append(spawnWeights, builder.Container())
+		}
+		if dino.ClassName == "" {
+			continue
+		}
+		if dino.ReplaceWith != nil {
+			builder := &fields.Builder{}
+			replacements = append(replacements, builder.AddString("FromClassName", dino.ClassName).AddString("ToClassName", *dino.ReplaceWith).Container())
+		}
+		if dino.DamageMultiplier != nil {
+			damage = append(damage, classMultiplier(dino.ClassName, *dino.DamageMultiplier))
+		}
+		if dino.TamedResistanceMultiplier != nil {
+			resistance = append(resistance, classMultiplier(dino.ClassName, *dino.TamedResistanceMultiplier))
+		}
+	}
+
+	fields.ReplaceKeysIgnoringCase(section, SpawnWeightsKey, spawnWeights)
+	fields.ReplaceKeysIgnoringCase(section, ReplacementsKey, replacements)
+	fields.ReplaceKeysIgnoringCase(section, DamageMultipliersKey, damage)
+	fields.ReplaceKeysIgnoringCase(section, ResistanceMultipliersKey, resistance)
+	var preventTame []string
+	for _, dino := range o.Dinos {
+		if dino.PreventTaming && dino.ClassName != "" {
+			preventTame = append(preventTame, fields.Quote(dino.ClassName))
+		}
+	}
+	fields.ReplaceKeysIgnoringCase(section, PreventTameKey, preventTame)
+}
+
+// read adds the entry of the key to the dino it refers to
+func (o *Overrides) read(keyName string, container ini.IniContainer) error {
+	if keyName == SpawnWeightsKey {
+		dino, err := o.Tag(fields.String(container, "DinoNameTag"))
+		if err != nil {
+			return err
+		}
+		if dino.SpawnWeightMultiplier, err = fields.OptionalFloat(container, "SpawnWeightMultiplier"); err != nil {
+			return err
+		}
+		if dino.OverrideSpawnLimitPercentage, err = fields.OptionalBool(container, "OverrideSpawnLimitPercentage"); err != nil {
+			return err
+		}
+		if dino.SpawnLimitPercentage, err = fields.OptionalFloat(container, "SpawnLimitPercentage"); err != nil {
+			return err
+		}
+		if dino.SpawnWeightMultiplier == nil {
+			dino.SpawnWeightMultiplier = floatPointer(1)
+		}
+		return nil
+	}
+
+	if keyName == ReplacementsKey {
+		dino, err := o.Class(fields.String(container, "FromClassName"))
+		if err != nil {
+			return err
+		}
+		replaceWith := fields.String(container, "ToClassName")
+		dino.ReplaceWith = &replaceWith
+		return nil
+	}
+
+	dino, err := o.Class(fields.String(container, "ClassName"))
+	if err != nil {
+		return err
+	}
+	multiplier, err := fields.Float(container, "Multiplier", 1)
+	if err != nil {
+		return err
+	}
+	if keyName == DamageMultipliersKey {
+		dino.DamageMultiplier = &multiplier
+	} else {
+		dino.TamedResistanceMultiplier = &multiplier
+	}
+	return nil
+}
+
+func classMultiplier(className string, multiplier float64) ini.IniContainer {
+	builder := &fields.Builder{}
+	return builder.AddString("ClassName", className).Add("Multiplier", multiplier).Container()
+}
+
+//endregion
+
+//region Lookup
+
+// Class returns the dino with the class name ignoring case, it is added if it doesn't exist. ErrEmptyName is returned for an empty class name.
+func (o *Overrides) Class(className string) (*Dino, error) {
+	if className == "" {
+		return nil, ErrEmptyName
+	}
+	for _, dino := range o.Dinos {
+		if strings.EqualFold(dino.ClassName, className) {
+			return dino, nil
+		}
+	}
+	dino := &Dino{ClassName: className}
+	o.Dinos = append(o.Dinos, dino)
+	return dino, nil
+}
+
+// Tag returns the first dino with the name tag ignoring case, it is added if it doesn't exist. ErrEmptyName is returned for an empty name tag.
+func (o *Overrides) Tag(nameTag string) (*Dino, error) {
+	if nameTag == "" {
+		return nil, ErrEmptyName
+	}
+	for _, dino := range o.Dinos {
+		if strings.EqualFold(dino.NameTag, nameTag) {
+			return dino, nil
+		}
+	}
+	dino := &Dino{NameTag: nameTag}
+	o.Dinos = append(o.Dinos, dino)
+	return dino, nil
+}
+
+// Merge fills in the class names and name tags of the dinos from the catalogue, and joins the spawn weights that are only known
+// by name tag with the other overrides of the classes that have that tag.
+// A class that already has a different spawn weight keeps it and is returned as a conflict, the spawn weight of the tag is then kept as a separate dino.
+func (o *Overrides) Merge(catalogue *Catalogue) []Conflict {
+	var conflicts []Conflict
+	for _, dino := range o.Dinos {
+		if dino.ClassName == "" || dino.NameTag != "" {
+			continue
+		}
+		if class, exists := catalogue.Class(dino.ClassName); exists {
+			dino.NameTag = class.NameTag
+		}
+	}
+
+	var merged []*Dino
+	for _, dino := range o.Dinos {
+		if dino.ClassName != "" {
+			merged = append(merged, dino)
+			continue
+		}
+
+		classes := catalogue.Tag(dino.NameTag)
+		if len(classes) == 0 {
+			merged = append(merged, dino)
+			continue
+		}
+		joined, conflicting := false, false
+		for _, other := range o.Dinos {
+			if other.ClassName == "" || !strings.EqualFold(other.NameTag, dino.NameTag) {
+				continue
+			}
+			joined = true
+			if !other.copySpawnWeight(dino) {
+				conflicting = true
+				conflicts = append(conflicts, Conflict{NameTag: dino.NameTag, ClassName: other.ClassName})
+			}
+		}
+		if !joined {
+			dino.ClassName = classes[0].ClassName
+		}
+		if !joined || conflicting {
+			merged = append(merged, dino)
+		}
+	}
+	o.Dinos = merged
+	return conflicts
+}
+
+// Unknown returns the overrides referring to a class or name tag that is not in the catalogue, replacements that stop a dino from spawning are not reported
+func (o *Overrides) Unknown(catalogue *Catalogue) []Reference {
+	var references []Reference
+	check := func(keyName string, name string, known bool) {
+		if !known {
+			references = append(references, Reference{Key: keyName, Name: name})
+		}
+	}
+
+	for _, dino := range o.Dinos {
+		if dino.SpawnWeightMultiplier != nil && dino.NameTag != "" {
+			check(SpawnWeightsKey, dino.NameTag, len(catalogue.Tag(dino.NameTag)) > 0)
+		}
+		if dino.ClassName == "" {
+			continue
+		}
+		_, known := catalogue.Class(dino.ClassName)
+		if dino.ReplaceWith != nil {
+			check(ReplacementsKey, dino.ClassName, known)
+			if *dino.ReplaceWith != "" {
+				_, replacementKnown := catalogue.Class(*dino.ReplaceWith)
+				check(ReplacementsKey, *dino.ReplaceWith, replacementKnown)
+			}
+		}
+		if dino.DamageMultiplier != nil {
+			check(DamageMultipliersKey, dino.ClassName, known)
+		}
+		if dino.TamedResistanceMultiplier != nil {
+			check(ResistanceMultipliersKey, dino.ClassName, known)
+		}
+		if dino.PreventTaming {
+			check(PreventTameKey, dino.ClassName, known)
+		}
+	}
+	return references
+}
+
+// copySpawnWeight copies the spawn weight of the other dino if the dino has none, it returns false if the dino already has a different spawn weight
+func (d *Dino) copySpawnWeight(other *Dino) bool {
+	if d.SpawnWeightMultiplier != nil {
+		return equalPointers(d.SpawnWeightMultiplier, other.SpawnWeightMultiplier) &&
+			equalPointers(d.OverrideSpawnLimitPercentage, other.OverrideSpawnLimitPercentage) &&
+			equalPointers(d.SpawnLimitPercentage, other.SpawnLimitPercentage)
+	}
+	d.SpawnWeightMultiplier = copyPointer(other.SpawnWeightMultiplier)
+	d.OverrideSpawnLimitPercentage = copyPointer(other.OverrideSpawnLimitPercentage)
+	d.SpawnLimitPercentage = copyPointer(other.SpawnLimitPercentage)
+	return true
+}
+
+func equalPointers[T comparable](a *T, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func copyPointer[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
+
+//endregion
+
+//region Catalogue
+
+// Class is a dino class known to the game or a mod
+type Class struct {
+	ClassName string `json:"className"`
+	NameTag   string `json:"nameTag"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Catalogue is a list of known dino classes
+type Catalogue struct {
+	Classes []Class
+}
+
+// LoadCatalogue reads a catalogue from a JSON file containing an array of classes e.g. [{"className":"Rex_Character_BP_C","nameTag":"Rex","name":"Rex"}]
+func LoadCatalogue(path string) (*Catalogue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalogue(data)
+}
+
+// ParseCatalogue reads a catalogue from JSON, see LoadCatalogue
+func ParseCatalogue(data []byte) (*Catalogue, error) {
+	var classes []Class
+	if err := json.Unmarshal(data, &classes); err != nil {
+		return nil, err
+	}
+	catalogue := &Catalogue{}
+	catalogue.Add(classes...)
+	return catalogue, nil
+}
+
+// Add adds the classes to the catalogue, a class that is already known is replaced
+func (c *Catalogue) Add(classes ...Class) {
+	for _, class := range classes {
+		replaced := false
+		for i := range c.Classes {
+			if strings.EqualFold(c.Classes[i].ClassName, class.ClassName) {
+				c.Classes[i] = class
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.Classes = append(c.Classes, class)
+		}
+	}
+}
+
+// Class returns the class with the name ignoring case
+func (c *Catalogue) Class(className string) (Class, bool) {
+	for _, class := range c.Classes {
+		if strings.EqualFold(class.ClassName, className) {
+			return class, true
+		}
+	}
+	return Class{}, false
+}
+
+// Tag returns all classes with the name tag ignoring case
+func (c *Catalogue) Tag(nameTag string) []Class {
+	var classes []Class
+	for _, class := range c.Classes {
+		if strings.EqualFold(class.NameTag, nameTag) {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+//endregion
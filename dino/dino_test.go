@@ -0,0 +1,168 @@
+package dino
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestLoad(t *testing.T) {
+	// ARK writes the section name in lower case after the first save
+	file, _ := ini.DeserializeIniFile(`[/script/shootergame.shootergamemode]
+DinoSpawnWeightMultipliers=(DinoNameTag=Rex,OverrideSpawnLimitPercentage=true,SpawnLimitPercentage=0.1)
+NPCReplacements=(FromClassName="Gigant_Character_BP_C",ToClassName="")
+DinoClassDamageMultipliers=(ClassName="Rex_Character_BP_C",Multiplier=1.5)
+tameddinoclassresistancemultipliers=(ClassName="rex_character_bp_c")
+PREVENTDINOTAMECLASSNAMES="Argent_Character_BP_C"
+`, Keys...)
+	overrides, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(overrides.Dinos) != 4 {
+		t.Fatalf("expected 4 dinos, got %+v", overrides.Dinos)
+	}
+	rex, _ := overrides.Class("REX_CHARACTER_BP_C")
+	if *rex.DamageMultiplier != 1.5 || *rex.TamedResistanceMultiplier != 1 || rex.SpawnWeightMultiplier != nil {
+		t.Errorf("unexpected class overrides %+v", rex)
+	}
+	rexTag, _ := overrides.Tag("rex")
+	if *rexTag.SpawnWeightMultiplier != 1 || !*rexTag.OverrideSpawnLimitPercentage || rexTag.ClassName != "" {
+		t.Errorf("a spawn weight without multiplier should default to 1 %+v", rexTag)
+	}
+	if gigant, _ := overrides.Class("Gigant_Character_BP_C"); gigant.ReplaceWith == nil || *gigant.ReplaceWith != "" {
+		t.Error("expected the giganotosaurus to be removed")
+	}
+	// Key names are matched ignoring case like the game does
+	if argent, _ := overrides.Class("Argent_Character_BP_C"); !argent.PreventTaming {
+		t.Error("expected the argentavis to be untameable")
+	}
+	overrides.Save(file)
+	if lines := strings.Split(file.ToString(), "\n"); len(lines) != 7 || !strings.HasPrefix(lines[4], ResistanceMultipliersKey+"=") || lines[5] != `PREVENTDINOTAMECLASSNAMES="Argent_Character_BP_C"` {
+		t.Errorf("keys written in another case were not kept in place:\n%s", file.ToString())
+	}
+
+	for _, line := range []string{
+		"DinoClassDamageMultipliers=(Multiplier=1.5)",
+		"DinoSpawnWeightMultipliers=(SpawnWeightMultiplier=0.5)",
+		`PreventDinoTameClassNames=""`,
+	} {
+		broken, _ := ini.DeserializeIniFile("[/Script/ShooterGame.ShooterGameMode]\n"+line+"\n", Keys...)
+		if _, err := Load(broken); !errors.Is(err, ErrEmptyName) {
+			t.Errorf("%s: expected ErrEmptyName, got %v", line, err)
+		}
+	}
+	if _, err := overrides.Tag(""); !errors.Is(err, ErrEmptyName) || len(overrides.Dinos) != 4 {
+		t.Error("an empty name tag should not add a dino")
+	}
+}
+
+func TestSave(t *testing.T) {
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+PreventDinoTameClassNames="Argent_Character_BP_C"
+DinoSpawnWeightMultipliers=(DinoNameTag=Dodo,SpawnWeightMultiplier=2.0)
+PreventDinoTameClassNames="Rex_Character_BP_C"
+MaxTamedDinos=5000
+`, Keys...)
+	overrides, _ := Load(file)
+	// Saving without changes keeps every line where it is
+	unchanged := file.ToString()
+	overrides.Save(file)
+	if output := file.ToString(); output != unchanged {
+		t.Errorf("unchanged overrides moved lines:\n%s", output)
+	}
+
+	argent, _ := overrides.Class("Argent_Character_BP_C")
+	argent.PreventTaming = false
+	// A spawn weight is written by name tag, so it is only written once per tag and not at all without one
+	stego, _ := overrides.Class("Stego_Character_BP_C")
+	stego.SpawnWeightMultiplier = floatPointer(3)
+	dodo, _ := overrides.Class("Dodo_Character_BP_C")
+	dodo.NameTag, dodo.SpawnWeightMultiplier = "dodo", floatPointer(5)
+	overrides.Save(file)
+
+	// The remaining class takes the place of the first line of the key
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+PreventDinoTameClassNames="Rex_Character_BP_C"
+DinoSpawnWeightMultipliers=(DinoNameTag=Dodo,SpawnWeightMultiplier=2.0)
+MaxTamedDinos=5000
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected file:\n%s", output)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	catalogue := &Catalogue{}
+	catalogue.Add(
+		Class{ClassName: "Rex_Character_BP_C", NameTag: "Rex"},
+		Class{ClassName: "Rex_Character_BP_Aberrant_C", NameTag: "Rex"},
+		Class{ClassName: "Dodo_Character_BP_C", NameTag: "Dodo"},
+	)
+
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+DinoSpawnWeightMultipliers=(DinoNameTag=Rex,SpawnWeightMultiplier=0.5)
+DinoSpawnWeightMultipliers=(DinoNameTag=Dodo,SpawnWeightMultiplier=2.0)
+DinoSpawnWeightMultipliers=(DinoNameTag=Wyvern,SpawnWeightMultiplier=0.0)
+DinoClassDamageMultipliers=(ClassName="Rex_Character_BP_C",Multiplier=1.5)
+DinoClassDamageMultipliers=(ClassName="Rex_Character_BP_Aberrant_C",Multiplier=1.2)
+PreventDinoTameClassNames="MyMod_Character_BP_C"
+`, Keys...)
+	overrides, _ := Load(file)
+	aberrant, _ := overrides.Class("Rex_Character_BP_Aberrant_C")
+	aberrant.NameTag, aberrant.SpawnWeightMultiplier = "Rex", floatPointer(0.1)
+
+	conflicts := overrides.Merge(catalogue)
+	if expected := []Conflict{{NameTag: "Rex", ClassName: "Rex_Character_BP_Aberrant_C"}}; !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("unexpected conflicts %+v", conflicts)
+	}
+
+	rex, _ := overrides.Class("Rex_Character_BP_C")
+	if rex.NameTag != "Rex" || *rex.SpawnWeightMultiplier != 0.5 || *rex.DamageMultiplier != 1.5 || *aberrant.SpawnWeightMultiplier != 0.1 {
+		t.Errorf("unexpected merged dinos %+v %+v", rex, aberrant)
+	}
+	// The conflicting tag stays, the dodo is joined with its class and the unknown wyvern is kept as it is
+	var tags []string
+	for _, dino := range overrides.Dinos {
+		tags = append(tags, dino.NameTag+"/"+dino.ClassName)
+	}
+	expectedTags := []string{"Rex/", "Dodo/Dodo_Character_BP_C", "Wyvern/", "Rex/Rex_Character_BP_C", "Rex/Rex_Character_BP_Aberrant_C", "/MyMod_Character_BP_C"}
+	if !reflect.DeepEqual(tags, expectedTags) {
+		t.Errorf("unexpected dinos %v", tags)
+	}
+
+	unknown := overrides.Unknown(catalogue)
+	expected := []Reference{{Key: SpawnWeightsKey, Name: "Wyvern"}, {Key: PreventTameKey, Name: "MyMod_Character_BP_C"}}
+	if !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("unexpected unknown references %+v", unknown)
+	}
+}
+
+func TestCatalogue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dinos.json")
+	data := `[{"className": "Rex_Character_BP_C", "nameTag": "Rex", "name": "Rex"}, {"className": "Dodo_Character_BP_C", "nameTag": "Dodo"}]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	catalogue, err := LoadCatalogue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalogue.Add(Class{ClassName: "rex_character_bp_c", NameTag: "Rex", Name: "Tyrannosaurus"})
+	if class, _ := catalogue.Class("Rex_Character_BP_C"); len(catalogue.Classes) != 2 || class.Name != "Tyrannosaurus" {
+		t.Errorf("expected the rex to be replaced, got %+v", catalogue.Classes)
+	}
+	if classes := catalogue.Tag("DODO"); len(classes) != 1 {
+		t.Errorf("expected the name tag to ignore case, got %+v", classes)
+	}
+	if _, err := ParseCatalogue([]byte("{")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
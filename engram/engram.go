@@ -0,0 +1,315 @@
+// Package engram reads and edits the engram overrides of a Game.ini file:
+// OverrideNamedEngramEntries, OverrideEngramEntries and EngramEntryAutoUnlocks.
+// Engram class names are compared case-insensitively, like the game does.
+package engram
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+const (
+	NamedEntriesKey   = "OverrideNamedEngramEntries"
+	IndexedEntriesKey = "OverrideEngramEntries"
+	AutoUnlocksKey    = "EngramEntryAutoUnlocks"
+)
+
+// Keys lists the engram keys, each of them is written once per engram so they must be allowed duplicate keys when parsing
+var Keys = []string{NamedEntriesKey, IndexedEntriesKey, AutoUnlocksKey}
+
+// Entry is an engram override, fields that are nil are not written and keep the default of the game
+type Entry struct {
+	// ClassName is the EngramClassName of an OverrideNamedEngramEntries entry
+	ClassName string
+	// Index is the EngramIndex of an OverrideEngramEntries entry
+	Index            *int
+	Hidden           *bool
+	PointsCost       *int
+	LevelRequirement *int
+	RemovePreReq     *bool
+}
+
+// AutoUnlock is an EngramEntryAutoUnlocks entry
+type AutoUnlock struct {
+	ClassName string
+	Level     int
+}
+
+// Overrides holds all engram overrides of a file in the order they appear
+type Overrides struct {
+	Named       []Entry
+	Indexed     []Entry
+	AutoUnlocks []AutoUnlock
+}
+
+// Conflict describes several entries for the same engram
+type Conflict struct {
+	// Key is the key the entries belong to
+	Key string
+	// Engram is the EngramClassName, or the EngramIndex for OverrideEngramEntries
+	Engram string
+	// Positions are the indexes of the entries in the matching slice of Overrides
+	Positions []int
+	// Conflicting is true if the entries have different values, false if they are exact duplicates
+	Conflicting bool
+}
+
+//region Loading and saving
+
+// Load reads all engram overrides from the file, the key names are matched ignoring case
+func Load(file *ini.IniFile) (*Overrides, error) {
+	overrides := &Overrides{}
+	section, exists := fields.FindSection(file, fields.GameModeSection)
+	if !exists {
+		return overrides, nil
+	}
+
+	for i, key := range section.Keys {
+		switch keyName := fields.KeyName(key.Key, Keys...); keyName {
+		case NamedEntriesKey, IndexedEntriesKey:
+			container, err := key.AsContainer()
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+			entry, err := entryFromContainer(container)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+			if keyName == NamedEntriesKey {
+				overrides.Named = append(overrides.Named, entry)
+			} else {
+				overrides.Indexed = append(overrides.Indexed, entry)
+			}
+		case AutoUnlocksKey:
+			container, err := key.AsContainer()
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+			level, err := fields.Int(container, "LevelToAutoUnlock", 0)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+			}
+			overrides.AutoUnlocks = append(overrides.AutoUnlocks, AutoUnlock{ClassName: fields.String(container, "EngramClassName"), Level: level})
+		}
+	}
+	return overrides, nil
+}
+
+// Save replaces all engram overrides in the file with the overrides, the keys are added to the allowed duplicate keys of the file
+func (o *Overrides) Save(file *ini.IniFile) {
+	fields.AllowDuplicates(file, Keys...)
+	section := fields.GetOrCreateSection(file, fields.GameModeSection)
+
+	var named, indexed, autoUnlocks []ini.IniContainer
+	for _, entry := range o.Named {
+		named = append(named, entry.ToContainer())
+	}
+	for _, entry := range o.Indexed {
+		indexed = append(indexed, entry.ToContainer())
+	}
+	for _, autoUnlock := range o.AutoUnlocks {
+		autoUnlocks = append(autoUnlocks, autoUnlock.ToContainer())
+	}
+
+	fields.ReplaceKeysIgnoringCase(section, NamedEntriesKey, named)
+	fields.ReplaceKeysIgnoringCase(section, IndexedEntriesKey, indexed)
+	fields.ReplaceKeysIgnoringCase(section, AutoUnlocksKey, autoUnlocks)
+}
+
+// ToContainer returns the entry in container format e.g. (EngramClassName="EngramEntry_CryoGun_Mod_C",EngramHidden=true,EngramPointsCost=0)
+func (e Entry) ToContainer() ini.IniContainer {
+	builder := &fields.Builder{}
+	if e.ClassName != "" {
+		builder.AddString("EngramClassName", e.ClassName)
+	}
+	fields.AddOptional(builder, "EngramIndex", e.Index)
+	fields.AddOptional(builder, "EngramHidden", e.Hidden)
+	fields.AddOptional(builder, "EngramPointsCost", e.PointsCost)
+	fields.AddOptional(builder, "EngramLevelRequirement", e.LevelRequirement)
+	fields.AddOptional(builder, "RemoveEngramPreReq", e.RemovePreReq)
+	return builder.Container()
+}
+
+// ToContainer returns the auto unlock in container format e.g. (EngramClassName="EngramEntry_Campfire_C",LevelToAutoUnlock=5)
+func (a AutoUnlock) ToContainer() ini.IniContainer {
+	builder := &fields.Builder{}
+	return builder.AddString("EngramClassName", a.ClassName).Add("LevelToAutoUnlock", a.Level).Container()
+}
+
+func entryFromContainer(container ini.IniContainer) (Entry, error) {
+	entry := Entry{ClassName: fields.String(container, "EngramClassName")}
+	var err error
+	if entry.Index, err = fields.OptionalInt(container, "EngramIndex"); err != nil {
+		return entry, err
+	}
+	if entry.Hidden, err = fields.OptionalBool(container, "EngramHidden"); err != nil {
+		return entry, err
+	}
+	if entry.PointsCost, err = fields.OptionalInt(container, "EngramPointsCost"); err != nil {
+		return entry, err
+	}
+	if entry.LevelRequirement, err = fields.OptionalInt(container, "EngramLevelRequirement"); err != nil {
+		return entry, err
+	}
+	if entry.RemovePreReq, err = fields.OptionalBool(container, "RemoveEngramPreReq"); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+//endregion
+
+//region Bulk operations
+
+// Entry returns the index in Named of the first entry for the class, an entry is added if it doesn't exist.
+// An index is returned instead of a pointer because appending to Named may move the entries.
+func (o *Overrides) Entry(className string) int {
+	for i := range o.Named {
+		if strings.EqualFold(o.Named[i].ClassName, className) {
+			return i
+		}
+	}
+	o.Named = append(o.Named, Entry{ClassName: className})
+	return len(o.Named) - 1
+}
+
+// Hide hides every named entry whose class matches the pattern (see path.Match) and returns the number of changed entries
+func (o *Overrides) Hide(pattern string) (int, error) {
+	return o.each(pattern, func(entry *Entry) {
+		entry.Hidden = boolPointer(true)
+	})
+}
+
+// HideTek hides every named entry of a tek engram (EngramEntry_Tek...) and returns the number of changed entries
+func (o *Overrides) HideTek() int {
+	count, _ := o.Hide("EngramEntry_Tek*")
+	return count
+}
+
+// SetCost sets the points cost of every named entry whose class matches the pattern (see path.Match) and returns the number of changed entries
+func (o *Overrides) SetCost(pattern string, cost int) (int, error) {
+	return o.each(pattern, func(entry *Entry) {
+		entry.PointsCost = intPointer(cost)
+	})
+}
+
+// UnlockByLevel makes every named entry with a level requirement of at most maxLevel unlock automatically at its level requirement and returns the number of changed auto unlocks
+func (o *Overrides) UnlockByLevel(maxLevel int) int {
+	count := 0
+	for _, entry := range o.Named {
+		if entry.LevelRequirement == nil || *entry.LevelRequirement > maxLevel {
+			continue
+		}
+		if o.setAutoUnlock(entry.ClassName, *entry.LevelRequirement) {
+			count++
+		}
+	}
+	return count
+}
+
+// Conflicts returns the engrams that have more than one entry in the same key
+func (o *Overrides) Conflicts() []Conflict {
+	var conflicts []Conflict
+	conflicts = append(conflicts, findConflicts(NamedEntriesKey, o.Named, func(entry Entry) string {
+		return entry.ClassName
+	})...)
+	conflicts = append(conflicts, findConflicts(IndexedEntriesKey, o.Indexed, func(entry Entry) string {
+		if entry.Index == nil {
+			return ""
+		}
+		return fmt.Sprint(*entry.Index)
+	})...)
+	conflicts = append(conflicts, findConflicts(AutoUnlocksKey, o.AutoUnlocks, func(autoUnlock AutoUnlock) string {
+		return autoUnlock.ClassName
+	})...)
+	return conflicts
+}
+
+func (o *Overrides) each(pattern string, fn func(entry *Entry)) (int, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range o.Named {
+		if matched, _ := path.Match(pattern, strings.ToLower(o.Named[i].ClassName)); matched {
+			fn(&o.Named[i])
+			count++
+		}
+	}
+	return count, nil
+}
+
+// setAutoUnlock sets the auto unlock level of the class and returns true if anything changed
+func (o *Overrides) setAutoUnlock(className string, level int) bool {
+	for i := range o.AutoUnlocks {
+		if strings.EqualFold(o.AutoUnlocks[i].ClassName, className) {
+			changed := o.AutoUnlocks[i].Level != level
+			o.AutoUnlocks[i].Level = level
+			return changed
+		}
+	}
+	o.AutoUnlocks = append(o.AutoUnlocks, AutoUnlock{ClassName: className, Level: level})
+	return true
+}
+
+// findConflicts groups the entries by engram, ignoring case, the Engram of a conflict is spelled as in its first entry
+func findConflicts[T any](keyName string, entries []T, engramOf func(entry T) string) []Conflict {
+	var conflicts []Conflict
+	positions := make(map[string][]int)
+	var order []string
+	for i, entry := range entries {
+		engram := strings.ToLower(engramOf(entry))
+		if engram == "" {
+			continue
+		}
+		if _, exists := positions[engram]; !exists {
+			order = append(order, engram)
+		}
+		positions[engram] = append(positions[engram], i)
+	}
+
+	for _, engram := range order {
+		if len(positions[engram]) < 2 {
+			continue
+		}
+		conflict := Conflict{Key: keyName, Engram: engramOf(entries[positions[engram][0]]), Positions: positions[engram]}
+		first := describe(entries[conflict.Positions[0]])
+		for _, position := range conflict.Positions[1:] {
+			if !strings.EqualFold(describe(entries[position]), first) {
+				conflict.Conflicting = true
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// describe returns a value that can be compared to find identical entries
+func describe(entry interface{}) string {
+	switch e := entry.(type) {
+	case Entry:
+		container := e.ToContainer()
+		return container.ToString()
+	case AutoUnlock:
+		container := e.ToContainer()
+		return container.ToString()
+	default:
+		return fmt.Sprintf("%+v", entry)
+	}
+}
+
+func boolPointer(value bool) *bool {
+	return &value
+}
+
+func intPointer(value int) *int {
+	return &value
+}
+
+//endregion
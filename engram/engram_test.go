@@ -0,0 +1,152 @@
+package engram
+
+import (
+	"strings"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestLoad(t *testing.T) {
+	// ARK writes the section name in lower case after the first save
+	file, _ := ini.DeserializeIniFile(`[/script/shootergame.shootergamemode]
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_CryoGun_Mod_C",EngramHidden=True,EngramPointsCost=0,RemoveEngramPreReq=False)
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_TekRifle_C")
+OverrideEngramEntries=(EngramIndex=0,EngramHidden=False)
+EngramEntryAutoUnlocks=(EngramClassName="EngramEntry_Campfire_C")
+`, Keys...)
+	overrides, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cryo := overrides.Named[0]
+	if len(overrides.Named) != 2 || !*cryo.Hidden || *cryo.PointsCost != 0 || *cryo.RemovePreReq || cryo.LevelRequirement != nil {
+		t.Errorf("unexpected entry %+v", cryo)
+	}
+	if tek := overrides.Named[1]; tek.Hidden != nil || tek.PointsCost != nil || tek.Index != nil {
+		t.Errorf("fields that are not written should be nil %+v", tek)
+	}
+	if len(overrides.Indexed) != 1 || *overrides.Indexed[0].Index != 0 || overrides.Indexed[0].ClassName != "" {
+		t.Errorf("unexpected indexed entries %+v", overrides.Indexed)
+	}
+	if len(overrides.AutoUnlocks) != 1 || overrides.AutoUnlocks[0].Level != 0 {
+		t.Errorf("a missing level should be 0 %+v", overrides.AutoUnlocks)
+	}
+
+	broken, _ := ini.DeserializeIniFile("[/Script/ShooterGame.ShooterGameMode]\nMaxPlayers=70\nOverrideNamedEngramEntries=(EngramClassName=\"EngramEntry_Forge_C\",EngramPointsCost=many)\n", Keys...)
+	if _, err := Load(broken); err == nil || !strings.Contains(err.Error(), "OverrideNamedEngramEntries line 1") {
+		t.Errorf("expected an error pointing to the entry, got %v", err)
+	}
+}
+
+func TestBulkOperations(t *testing.T) {
+	overrides := &Overrides{
+		Named: []Entry{
+			{ClassName: "EngramEntry_TekRifle_C", LevelRequirement: intPointer(100)},
+			{ClassName: "EngramEntry_Campfire_C", LevelRequirement: intPointer(2)},
+			{ClassName: "EngramEntry_Forge_C"},
+		},
+		AutoUnlocks: []AutoUnlock{{ClassName: "engramentry_campfire_c", Level: 2}},
+	}
+
+	if count := overrides.HideTek(); count != 1 || !*overrides.Named[0].Hidden || overrides.Named[1].Hidden != nil {
+		t.Errorf("only the tek engram should be hidden, %d changed", count)
+	}
+	if _, err := overrides.SetCost("[", 1); err == nil {
+		t.Error("expected an error for a bad pattern")
+	}
+	// The existing auto unlock already has the level and the forge has no level requirement
+	if count := overrides.UnlockByLevel(50); count != 0 || len(overrides.AutoUnlocks) != 1 {
+		t.Errorf("expected nothing to change, got %d %+v", count, overrides.AutoUnlocks)
+	}
+	if count := overrides.UnlockByLevel(100); count != 1 || overrides.AutoUnlocks[1] != (AutoUnlock{ClassName: "EngramEntry_TekRifle_C", Level: 100}) {
+		t.Errorf("expected the tek rifle to unlock, got %d %+v", count, overrides.AutoUnlocks)
+	}
+}
+
+func TestSaveToEmptyFile(t *testing.T) {
+	file := ini.NewIniFile()
+	overrides := &Overrides{Indexed: []Entry{{Index: intPointer(3), Hidden: boolPointer(true)}}}
+	overrides.Save(file)
+	overrides.Save(file)
+
+	expected := "[/Script/ShooterGame.ShooterGameMode]\nOverrideEngramEntries=(EngramIndex=3,EngramHidden=true)\n"
+	if file.ToString() != expected || len(file.AllowedDuplicateKeys) != len(Keys) {
+		t.Errorf("unexpected file:\n%s", file.ToString())
+	}
+}
+
+func TestConflictingEntries(t *testing.T) {
+	overrides := &Overrides{}
+	overrides.Named[overrides.Entry("EngramEntry_Campfire_C")].PointsCost = intPointer(1)
+	overrides.Named = append(overrides.Named, Entry{ClassName: "engramentry_campfire_c", PointsCost: intPointer(2)})
+	overrides.Named[overrides.Entry("ENGRAMENTRY_CAMPFIRE_C")].Hidden = boolPointer(true)
+
+	conflicts := overrides.Conflicts()
+	if len(conflicts) != 1 || !conflicts[0].Conflicting || len(conflicts[0].Positions) != 2 || conflicts[0].Engram != "EngramEntry_Campfire_C" {
+		t.Errorf("unexpected conflicts %+v", conflicts)
+	}
+	container := overrides.Named[0].ToContainer()
+	if len(overrides.Named) != 2 || !strings.Contains(container.ToString(), "EngramHidden=true") {
+		t.Error("Entry did not return the existing entry")
+	}
+
+	overrides.AutoUnlocks = []AutoUnlock{{ClassName: "EngramEntry_Campfire_C", Level: 2}, {ClassName: "engramentry_campfire_c", Level: 2}}
+	if conflicts := overrides.Conflicts(); len(conflicts) != 2 || conflicts[1].Conflicting {
+		t.Errorf("auto unlocks that only differ in case should be duplicates %+v", conflicts)
+	}
+	if count, _ := overrides.Hide("engramentry_CAMPFIRE*"); count != 2 {
+		t.Errorf("expected the pattern to ignore case, %d entries changed", count)
+	}
+}
+
+func TestSaveKeepsPositions(t *testing.T) {
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Campfire_C",EngramPointsCost=3)
+bAllowUnlimitedRespecs=True
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_TekRifle_C",EngramLevelRequirement=100)
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Forge_C",EngramLevelRequirement=20)
+MaxTamedDinos=5000
+`, Keys...)
+	overrides, _ := Load(file)
+	overrides.Named[overrides.Entry("EngramEntry_TekRifle_C")].Hidden = boolPointer(true)
+	overrides.Named = append(overrides.Named[:2], Entry{ClassName: "EngramEntry_Bed_C", PointsCost: intPointer(2)}, Entry{ClassName: "EngramEntry_Cot_C"})
+	overrides.Save(file)
+
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Campfire_C",EngramPointsCost=3)
+bAllowUnlimitedRespecs=True
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_TekRifle_C",EngramHidden=true,EngramLevelRequirement=100)
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Bed_C",EngramPointsCost=2)
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Cot_C")
+MaxTamedDinos=5000
+`
+	if file.ToString() != expected {
+		t.Errorf("unexpected file:\n%s", file.ToString())
+	}
+}
+
+func TestKeyNamesIgnoreCase(t *testing.T) {
+	// A hand edited line the game still reads
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+overridenamedengramentries=(EngramClassName="EngramEntry_Campfire_C",EngramPointsCost=3)
+ENGRAMENTRYAUTOUNLOCKS=(EngramClassName="EngramEntry_Campfire_C",LevelToAutoUnlock=2)
+MaxTamedDinos=5000
+`)
+	overrides, _ := Load(file)
+	if len(overrides.Named) != 1 || len(overrides.AutoUnlocks) != 1 {
+		t.Fatalf("expected the keys to be read, got %+v", overrides)
+	}
+
+	overrides.Named[0].PointsCost = intPointer(4)
+	overrides.Save(file)
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Campfire_C",EngramPointsCost=4)
+ENGRAMENTRYAUTOUNLOCKS=(EngramClassName="EngramEntry_Campfire_C",LevelToAutoUnlock=2)
+MaxTamedDinos=5000
+`
+	if file.ToString() != expected {
+		t.Errorf("expected the keys to be replaced in place:\n%s", file.ToString())
+	}
+}
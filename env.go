@@ -0,0 +1,233 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvChange describes the change ApplyEnv makes, or PlanEnv would make, for one environment variable
+type EnvChange struct {
+	// Variable is the name of the environment variable
+	Variable string
+	// Section is the name of the section the variable maps to
+	Section string
+	// Key is the name of the key the variable maps to
+	Key string
+	// Index is the position of the key among the keys with the same name, it is -1 if the variable has no index
+	Index int
+	// OldValue is the current value of the key, it is nil if the key will be created
+	OldValue interface{}
+	// NewValue is the value from the variable, converted to the type of the current value
+	NewValue interface{}
+	// Err is set when the variable cannot be applied, nothing is changed for it
+	Err error
+
+	position int
+}
+
+// knownSections are created when a variable refers to them and the file doesn't have them yet
+var knownSections = []string{
+	"ServerSettings",
+	"SessionSettings",
+	"MessageOfTheDay",
+	"ScalabilityGroups",
+	"/Script/Engine.GameSession",
+	"/Script/ShooterGame.ShooterGameMode",
+	"/Script/ShooterGame.ShooterGameUserSettings",
+}
+
+// ApplyEnv sets keys of the file from the environment variables starting with prefix, all changes are a single operation for observers and History.
+//
+// Variables are named <PREFIX>_<SECTION>__<KEY> or <PREFIX>_<SECTION>__<KEY>__<INDEX>, e.g. ARK_SERVERSETTINGS__MAXPLAYERS=70:
+//   - The prefix is matched ignoring case and must be followed by an underscore, it may contain the name of the file to target one of several files e.g. ARKINI_GameUserSettings.
+//   - Section and key names are matched ignoring case and every character that is not a letter or digit,
+//     so SCRIPT_SHOOTERGAME_SHOOTERGAMEMODE matches /Script/ShooterGame.ShooterGameMode.
+//   - A section that doesn't exist is only created if it is one of the common ARK sections, a key that doesn't exist is created with the name written in the variable.
+//   - Keys that appear more than once need an index, the index of the nth key starting at 0 replaces its value and an index equal to the number of keys adds a key.
+//   - Without a double underscore the section and key are separated by the last underscore that leaves an existing or known section,
+//     e.g. ARKINI_GameUserSettings_ServerSettings_DifficultyOffset=1 with the prefix ARKINI_GameUserSettings. Indexes are not supported in this form.
+//
+// Values are converted to the type of the current value of the key, new keys get the guessed type.
+// Variables that cannot be applied are reported in the returned changes and joined in the returned error, the other variables are still applied.
+func ApplyEnv(file *IniFile, prefix string) ([]EnvChange, error) {
+	return applyEnv(file, prefix, os.Environ())
+}
+
+// PlanEnv returns the changes ApplyEnv would make without changing the file
+func PlanEnv(file *IniFile, prefix string) []EnvChange {
+	return planEnv(file, prefix, os.Environ())
+}
+
+func applyEnv(file *IniFile, prefix string, environ []string) ([]EnvChange, error) {
+	changes := planEnv(file, prefix, environ)
+	defer file.beginOp()()
+
+	var errs []error
+	for _, change := range changes {
+		if change.Err != nil {
+			errs = append(errs, change.Err)
+			continue
+		}
+
+		section := file.GetOrCreateSection(change.Section)
+		if change.position < 0 {
+			section.AddKey(change.Key, change.NewValue)
+		} else {
+			section.setKeyAt(change.position, change.NewValue, "")
+		}
+	}
+	return changes, errors.Join(errs...)
+}
+
+// planEnv returns the changes for the variables of environ starting with prefix, sorted by variable name
+func planEnv(file *IniFile, prefix string, environ []string) []EnvChange {
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+	var changes []EnvChange
+	for _, variable := range environ {
+		name, value, found := strings.Cut(variable, "=")
+		if !found || len(name) <= len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+			continue
+		}
+
+		change := planEnvVariable(file, name, name[len(prefix):], value)
+		if change.Err != nil {
+			change.Err = fmt.Errorf("%s: %w", name, change.Err)
+		} else if change.OldValue != nil && formatValue(change.OldValue) == formatValue(change.NewValue) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Variable < changes[j].Variable
+	})
+	return changes
+}
+
+func planEnvVariable(file *IniFile, variable string, name string, value string) EnvChange {
+	change := EnvChange{Variable: variable, Index: -1, position: -1}
+
+	sectionName, keyName, index, err := splitEnvName(file, name)
+	if err != nil {
+		change.Err = err
+		return change
+	}
+	change.Index = index
+
+	section, exists := findEnvSection(file, sectionName)
+	if !exists {
+		change.Err = fmt.Errorf("%w: %q", ErrSectionNotFound, sectionName)
+		return change
+	}
+	change.Section = section
+	change.Key = keyName
+
+	var positions []int
+	existing, sectionExists := file.GetSection(section)
+	if sectionExists {
+		for i, key := range existing.Keys {
+			if normalizeEnvName(key.Key) == normalizeEnvName(keyName) {
+				positions = append(positions, i)
+				change.Key = key.Key
+			}
+		}
+	}
+
+	switch {
+	case index < 0 && len(positions) > 1:
+		change.Err = fmt.Errorf("%w: key %q appears %d times, add __<index> to the variable", ErrInvalidEnv, change.Key, len(positions))
+		return change
+	case index < 0 && len(positions) == 1:
+		change.position = positions[0]
+	case index >= 0 && index < len(positions):
+		change.position = positions[index]
+	case index > len(positions):
+		change.Err = fmt.Errorf("%w: index %d of key %q, it appears %d times", ErrKeyNotFound, index, change.Key, len(positions))
+		return change
+	}
+
+	if change.position < 0 {
+		change.NewValue = toGuessedType(value)
+		return change
+	}
+	change.OldValue = existing.Keys[change.position].Value
+	change.NewValue, change.Err = coerceEnvValue(value, change.OldValue)
+	return change
+}
+
+// splitEnvName splits the part of the variable name after the prefix into the section, key and index, the index is -1 if there is none
+func splitEnvName(file *IniFile, name string) (string, string, int, error) {
+	parts := strings.Split(name, "__")
+	switch len(parts) {
+	case 1:
+		segments := strings.Split(name, "_")
+		for i := len(segments) - 1; i > 0; i-- {
+			sectionName := strings.Join(segments[:i], "_")
+			if _, exists := findEnvSection(file, sectionName); exists {
+				return sectionName, strings.Join(segments[i:], "_"), -1, nil
+			}
+		}
+		return "", "", -1, fmt.Errorf("%w: no section found in %q", ErrSectionNotFound, name)
+	case 2, 3:
+		if parts[0] == "" || parts[1] == "" {
+			return "", "", -1, fmt.Errorf("%w: empty section or key in %q", ErrInvalidEnv, name)
+		}
+		if len(parts) == 2 {
+			return parts[0], parts[1], -1, nil
+		}
+		index, err := strconv.Atoi(parts[2])
+		if err != nil || index < 0 {
+			return "", "", -1, fmt.Errorf("%w: invalid index %q", ErrInvalidEnv, parts[2])
+		}
+		return parts[0], parts[1], index, nil
+	default:
+		return "", "", -1, fmt.Errorf("%w: too many double underscores in %q", ErrInvalidEnv, name)
+	}
+}
+
+// findEnvSection returns the name of the section of the file, or of the known sections, that matches the name from a variable
+func findEnvSection(file *IniFile, name string) (string, bool) {
+	normalized := normalizeEnvName(name)
+	for _, section := range file.Sections {
+		if normalizeEnvName(section.SectionName) == normalized {
+			return section.SectionName, true
+		}
+	}
+	for _, sectionName := range knownSections {
+		if normalizeEnvName(sectionName) == normalized {
+			return sectionName, true
+		}
+	}
+	return "", false
+}
+
+// normalizeEnvName returns the name in lower case without any characters that are not letters or digits
+func normalizeEnvName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// coerceEnvValue converts the value to the type of the current value, whole numbers may become decimals e.g. 1 can be set to 0.5
+func coerceEnvValue(value string, current interface{}) (interface{}, error) {
+	parser := valueParser{}
+	switch typeOfValue(current) {
+	case Int:
+		if converted, err := parser.parseAs(value, Int); err == nil {
+			return converted, nil
+		}
+		return parser.parseAs(value, Float64)
+	case Float64, Boolean, Container:
+		return parser.parseAs(value, typeOfValue(current))
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,89 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrSectionNotFound is returned when a section does not exist
+	ErrSectionNotFound = errors.New("section not found")
+	// ErrKeyNotFound is returned when a key does not exist
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrTypeMismatch is returned when a value does not have, or cannot be converted to, the requested type
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrNoValue is returned when a value is required but none was provided
+	ErrNoValue = errors.New("no value(s) provided")
+	// ErrEmptyInput is returned when there is nothing to parse
+	ErrEmptyInput = errors.New("input is empty")
+	// ErrNothingToUndo is returned by History.Undo when there is no change to undo
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by History.Redo when there is no change to redo
+	ErrNothingToRedo = errors.New("nothing to redo")
+	// ErrCheckpointNotFound is returned when a checkpoint does not exist or is no longer reachable
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	// ErrInvalidPath is returned when a query path cannot be parsed
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrHistoryMismatch is returned when the file was changed without going through the mutation methods and a change cannot be reverted
+	ErrHistoryMismatch = errors.New("history does not match the file")
+	// ErrInvalidEnv is returned by ApplyEnv when the name of an environment variable does not follow the naming scheme
+	ErrInvalidEnv = errors.New("invalid environment variable")
+	// ErrConflict is returned by SaveFileIfUnchanged when the file on disk was changed since it was loaded
+	ErrConflict = errors.New("file was changed on disk")
+)
+
+// KeyError describes an error that happened on a specific key, use errors.Is to check the cause e.g. errors.Is(err, ErrKeyNotFound)
+type KeyError struct {
+	// Section is the name of the section of the key, it is empty if the section is unknown
+	Section string
+	// Key is the name of the key
+	Key string
+	// Expected is the requested type, it is empty if the error is not about a type
+	Expected KeyType
+	// Actual is the type of the value, it is empty if the error is not about a type
+	Actual KeyType
+	// Err is the cause of the error
+	Err error
+}
+
+// Error returns the error as a string e.g. `section "ServerSettings" key "MaxPlayers": type mismatch (expected int, got string)`
+func (e *KeyError) Error() string {
+	var builder strings.Builder
+	if e.Section != "" {
+		builder.WriteString(fmt.Sprintf("section %q ", e.Section))
+	}
+	builder.WriteString(fmt.Sprintf("key %q: %v", e.Key, e.Err))
+	if e.Expected != "" || e.Actual != "" {
+		builder.WriteString(fmt.Sprintf(" (expected %s, got %s)", e.Expected, e.Actual))
+	}
+	return builder.String()
+}
+
+// Unwrap returns the cause of the error
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// newTypeError returns a KeyError for a value of the key that is not of the expected type
+func newTypeError(keyName string, expected KeyType, value interface{}) *KeyError {
+	return &KeyError{Key: keyName, Expected: expected, Actual: typeOfValue(value), Err: ErrTypeMismatch}
+}
+
+// typeOfValue returns the KeyType of a key value
+func typeOfValue(value interface{}) KeyType {
+	switch value.(type) {
+	case string:
+		return String
+	case int:
+		return Int
+	case float64:
+		return Float64
+	case bool:
+		return Boolean
+	case IniContainer, []ContainerKey:
+		return Container
+	default:
+		return Fail
+	}
+}
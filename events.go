@@ -0,0 +1,100 @@
+package ini
+
+// ChangeType is the kind of change described by a ChangeEvent
+type ChangeType string
+
+const (
+	// SectionAdded is emitted when a section is added to the file, NewValue is the *IniSection
+	SectionAdded ChangeType = "section_added"
+	// SectionRemoved is emitted when a section is removed from the file, OldValue is the *IniSection
+	SectionRemoved ChangeType = "section_removed"
+	// KeyAdded is emitted when a key is added to a section, NewValue is the value of the key
+	KeyAdded ChangeType = "key_added"
+	// KeySet is emitted when the value of an existing key is replaced, OldValue and NewValue are the values before and after
+	KeySet ChangeType = "key_set"
+	// KeyRemoved is emitted when a key is removed from a section, OldValue is the value of the key
+	KeyRemoved ChangeType = "key_removed"
+)
+
+// ChangeEvent describes a single change made through the mutation methods of IniFile and IniSection
+type ChangeEvent struct {
+	Type ChangeType
+	// Op identifies the call that caused the change, all events caused by the same call (e.g. OverwriteKey) share the same Op
+	Op uint64
+	// Section is the name of the section that changed or that holds the key that changed
+	Section string
+	// Key is the name of the key that changed, it is empty for section events
+	Key string
+	// Index is the position of the section in the file or of the key in the section at the moment of the change
+	Index    int
+	OldValue interface{}
+	NewValue interface{}
+
+	section *IniSection
+	// oldRaw and newRaw are the Raw texts of the key before and after the change, so undo and redo write the values as they were written
+	oldRaw string
+	newRaw string
+}
+
+// Observer receives the changes made to an IniFile
+type Observer func(event ChangeEvent)
+
+type observerEntry struct {
+	id       uint64
+	observer Observer
+}
+
+// Subscribe registers an observer that is called after every change made to the file or to its sections, the returned function removes the observer.
+// Only sections created by the file (GetOrCreateSection and the deserialize functions) report their changes, observers are not copied by Clone.
+func (f *IniFile) Subscribe(observer Observer) (unsubscribe func()) {
+	f.lastObserverID++
+	id := f.lastObserverID
+	f.observers = append(f.observers, observerEntry{id: id, observer: observer})
+
+	return func() {
+		for i, entry := range f.observers {
+			if entry.id == id {
+				f.observers = append(f.observers[:i:i], f.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// beginOp starts an operation, all events emitted until the returned function is called share the same Op. Nested operations join the outer one.
+func (f *IniFile) beginOp() (end func()) {
+	if f.opDepth == 0 {
+		f.lastOp++
+	}
+	f.opDepth++
+	return func() {
+		f.opDepth--
+	}
+}
+
+// emit sends the event to all observers
+func (f *IniFile) emit(event ChangeEvent) {
+	if len(f.observers) == 0 {
+		return
+	}
+	event.Op = f.lastOp
+	for _, entry := range f.observers {
+		entry.observer(event)
+	}
+}
+
+// beginOp starts an operation on the file of the section, see IniFile.beginOp
+func (s *IniSection) beginOp() (end func()) {
+	if s.file == nil {
+		return func() {}
+	}
+	return s.file.beginOp()
+}
+
+// emitKeyEvent sends an event about a key of the section to the observers of the file of the section
+func (s *IniSection) emitKeyEvent(changeType ChangeType, keyName string, index int, oldValue interface{}, newValue interface{}, oldRaw string, newRaw string) {
+	if s.file == nil {
+		return
+	}
+	s.file.emit(ChangeEvent{Type: changeType, Section: s.SectionName, Key: keyName, Index: index, OldValue: oldValue, NewValue: newValue, section: s, oldRaw: oldRaw, newRaw: newRaw})
+}
@@ -1,12 +1,13 @@
 package ini
 
-import (
-	"errors"
-)
-
 type IniFile struct {
 	AllowedDuplicateKeys []string
 	Sections             []*IniSection
+
+	observers      []observerEntry
+	lastObserverID uint64
+	lastOp         uint64
+	opDepth        int
 }
 
 func NewIniFile(allowedDuplicateKeys ...string) *IniFile {
@@ -36,12 +37,21 @@ func (f *IniFile) GetSection(sectionName string) (*IniSection, bool) {
 func (f *IniFile) GetOrCreateSection(sectionName string) *IniSection {
 	section, exists := f.GetSection(sectionName)
 	if !exists {
-		section = NewIniSection(sectionName, &f.AllowedDuplicateKeys)
+		defer f.beginOp()()
+		section = f.newSection(sectionName)
 		f.Sections = append(f.Sections, section)
+		f.emit(ChangeEvent{Type: SectionAdded, Section: sectionName, Index: len(f.Sections) - 1, NewValue: section, section: section})
 	}
 	return section
 }
 
+// newSection returns a new section that uses the AllowedDuplicateKeys of the file and reports its changes to the file, the section is not added to the file
+func (f *IniFile) newSection(sectionName string) *IniSection {
+	section := NewIniSection(sectionName, &f.AllowedDuplicateKeys)
+	section.file = f
+	return section
+}
+
 // GetKeyFromSection returns the key with the given name from the section with the given name
 func (f *IniFile) GetKeyFromSection(sectionName string, keyName string) (*IniKey, error) {
 	section, exists := f.GetSection(sectionName)
@@ -50,9 +60,9 @@ func (f *IniFile) GetKeyFromSection(sectionName string, keyName string) (*IniKey
 		if exists {
 			return key, nil
 		}
-		return nil, errors.New("key not found")
+		return nil, &KeyError{Section: sectionName, Key: keyName, Err: ErrKeyNotFound}
 	}
-	return nil, errors.New("section not found")
+	return nil, &KeyError{Section: sectionName, Key: keyName, Err: ErrSectionNotFound}
 }
 
 // GetKeyFromSectionWithMultipleValues returns all the keys with the given name from the section with the given name
@@ -61,14 +71,15 @@ func (f *IniFile) GetKeyFromSectionWithMultipleValues(sectionName string, keyNam
 	if exists {
 		return section.FindKeys(keyName)
 	}
-	return nil, errors.New("section not found")
+	return nil, &KeyError{Section: sectionName, Key: keyName, Err: ErrSectionNotFound}
 }
 
 // RemoveSection removes the section with the given name from the file
 func (f *IniFile) RemoveSection(sectionName string) {
 	for i, section := range f.Sections {
 		if section.SectionName == sectionName {
-			f.Sections = append(f.Sections[:i], f.Sections[i+1:]...)
+			defer f.beginOp()()
+			f.removeSectionAt(i)
 			return
 		}
 	}
@@ -76,11 +87,29 @@ func (f *IniFile) RemoveSection(sectionName string) {
 
 // RemoveAllSections removes all sections from the file
 func (f *IniFile) RemoveAllSections() {
+	defer f.beginOp()()
+	for i := len(f.Sections) - 1; i >= 0; i-- {
+		f.removeSectionAt(i)
+	}
 	f.Sections = make([]*IniSection, 0)
 }
 
+// removeSectionAt removes the section at index i from the file
+func (f *IniFile) removeSectionAt(i int) {
+	section := f.Sections[i]
+	f.Sections = append(f.Sections[:i], f.Sections[i+1:]...)
+	f.emit(ChangeEvent{Type: SectionRemoved, Section: section.SectionName, Index: i, OldValue: section, section: section})
+}
+
+// insertSectionAt inserts the section at index i in the file
+func (f *IniFile) insertSectionAt(i int, section *IniSection) {
+	f.Sections = append(f.Sections[:i], append([]*IniSection{section}, f.Sections[i:]...)...)
+	f.emit(ChangeEvent{Type: SectionAdded, Section: section.SectionName, Index: i, NewValue: section, section: section})
+}
+
 // SafelyAddKeyToSection same as the others but will automatically check if duplicates are allowed, if so it will add the key, if not it will replace it.
 func (f *IniFile) SafelyAddKeyToSection(sectionName string, keyName string, value interface{}) {
+	defer f.beginOp()()
 	if f.duplicateAllowed(keyName) {
 		f.AddKeyToSection(sectionName, keyName, value)
 	} else {
@@ -91,12 +120,14 @@ func (f *IniFile) SafelyAddKeyToSection(sectionName string, keyName string, valu
 
 // AddKeyToSection adds a key to the section with the given name, if the section does not exist it is created
 func (f *IniFile) AddKeyToSection(sectionName string, keyName string, value interface{}) {
+	defer f.beginOp()()
 	section := f.GetOrCreateSection(sectionName)
 	section.AddKey(keyName, value)
 }
 
 // UpdateOrCreateKeyInSection updates the value of the key with the given name in the section with the given name, or creates a new key with the given name and value in the section. If the section does not exist it is created.
 func (f *IniFile) UpdateOrCreateKeyInSection(sectionName string, keyName string, value interface{}) {
+	defer f.beginOp()()
 	section := f.GetOrCreateSection(sectionName)
 	section.AddOrReplaceKey(keyName, value)
 }
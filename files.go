@@ -0,0 +1,102 @@
+package ini
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// LoadFile reads and parses the ini file at path, the types of the values are guessed
+func LoadFile(path string, allowedDuplicateKeys ...string) (*IniFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DeserializeIniFile(string(data), allowedDuplicateKeys...)
+}
+
+// SaveFile writes the file to path. The data is written to a temporary file in the same directory first and then renamed,
+// so a crash or a server reading the file at the same time never sees a partially written file.
+func SaveFile(path string, file *IniFile) error {
+	return writeFileAtomic(path, []byte(file.ToString()))
+}
+
+// SaveFileIfUnchanged writes the file to path like SaveFile, but only if the Hash of the file on disk is still expectedHash,
+// e.g. the hash of the file when it was loaded. Otherwise the file was changed by someone else, e.g. the game server, and ErrConflict is returned.
+// An empty expectedHash means the file must not exist yet.
+// The check and the write are not atomic, a write by another process in between is not detected.
+func SaveFileIfUnchanged(path string, file *IniFile, expectedHash string) error {
+	current, err := LoadFile(path, file.AllowedDuplicateKeys...)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if expectedHash != "" {
+			return fmt.Errorf("%w: %s was removed", ErrConflict, path)
+		}
+	case err != nil:
+		return err
+	case expectedHash == "":
+		return fmt.Errorf("%w: %s already exists", ErrConflict, path)
+	case current.Hash() != expectedHash:
+		return fmt.Errorf("%w: %s was changed", ErrConflict, path)
+	}
+	return SaveFile(path, file)
+}
+
+// Hash returns the hex encoded sha256 of the sections, keys and values of the file in order.
+// Whitespace, comments and the way values are written, e.g. True and true or 1.0 and 1, don't change the hash, so it is the same for files that are Equal.
+// The types of the values are guessed from their text again, so the hash doesn't depend on the ParseOptions the file was parsed with.
+func (f *IniFile) Hash() string {
+	hash := sha256.New()
+	write := func(kind byte, text string) {
+		hash.Write([]byte{kind})
+		hash.Write([]byte(strconv.Itoa(len(text))))
+		hash.Write([]byte{':'})
+		hash.Write([]byte(text))
+	}
+	for _, section := range f.Sections {
+		write('s', section.SectionName)
+		for _, key := range section.Keys {
+			write('k', key.Key)
+			write('v', formatValue(toGuessedType(formatValue(key.Value))))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it to path, the mode of an existing file is kept
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+	ownWrites.record(path, data)
+	return nil
+}
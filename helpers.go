@@ -34,7 +34,15 @@ func checkValueType(value string) KeyType {
 func toGuessedType(value string) interface{} {
 	switch checkValueType(value) {
 	case Int:
-		intValue, _ := strconv.Atoi(value)
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			// Whole numbers written like "70.0" or "1e5", numbers that don't fit in an int stay a float
+			floatValue, _ := strconv.ParseFloat(value, 64)
+			if floatValue >= float64(math.MaxInt) || floatValue < float64(math.MinInt) {
+				return floatValue
+			}
+			return int(floatValue)
+		}
 		return intValue
 	case Float64:
 		floatValue, _ := strconv.ParseFloat(value, 64)
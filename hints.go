@@ -0,0 +1,158 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//region ParseOptions
+
+// ParseOptions controls how values are turned into Go values while parsing
+type ParseOptions struct {
+	// RawStrings keeps every value that is not a container as the string it was written as instead of guessing its type
+	RawStrings bool
+	// Hints gives the type of specific keys and container fields, a hint takes precedence over RawStrings and guessing
+	Hints *TypeHints
+}
+
+//endregion
+
+//region TypeHints
+
+// TypeHints is a registry of the intended types of keys and container fields
+type TypeHints struct {
+	keys   map[hintKey]KeyType
+	fields map[hintKey]KeyType
+}
+
+type hintKey struct {
+	sectionName string
+	keyName     string
+	fieldName   string
+}
+
+// NewTypeHints returns an empty TypeHints registry
+func NewTypeHints() *TypeHints {
+	return &TypeHints{
+		keys:   make(map[hintKey]KeyType),
+		fields: make(map[hintKey]KeyType),
+	}
+}
+
+// SetKey sets the type of the key with the given name in the given section, an empty section name matches the key in every section
+func (h *TypeHints) SetKey(sectionName string, keyName string, keyType KeyType) *TypeHints {
+	h.keys[hintKey{sectionName: sectionName, keyName: keyName}] = keyType
+	return h
+}
+
+// SetField sets the type of a container field of the key with the given name at any depth, an empty section name matches the key in every section
+func (h *TypeHints) SetField(sectionName string, keyName string, fieldName string, keyType KeyType) *TypeHints {
+	h.fields[hintKey{sectionName: sectionName, keyName: keyName, fieldName: fieldName}] = keyType
+	return h
+}
+
+// KeyType returns the hinted type of the key and true, or Fail and false if there is no hint
+func (h *TypeHints) KeyType(sectionName string, keyName string) (KeyType, bool) {
+	if h == nil {
+		return Fail, false
+	}
+	if keyType, ok := h.keys[hintKey{sectionName: sectionName, keyName: keyName}]; ok {
+		return keyType, true
+	}
+	keyType, ok := h.keys[hintKey{keyName: keyName}]
+	return keyType, ok
+}
+
+// FieldType returns the hinted type of the container field and true, or Fail and false if there is no hint
+func (h *TypeHints) FieldType(sectionName string, keyName string, fieldName string) (KeyType, bool) {
+	if h == nil {
+		return Fail, false
+	}
+	if keyType, ok := h.fields[hintKey{sectionName: sectionName, keyName: keyName, fieldName: fieldName}]; ok {
+		return keyType, true
+	}
+	keyType, ok := h.fields[hintKey{keyName: keyName, fieldName: fieldName}]
+	return keyType, ok
+}
+
+//endregion
+
+//region valueParser
+
+// valueParser parses the values of a single key according to the parse options
+type valueParser struct {
+	options     ParseOptions
+	sectionName string
+	keyName     string
+}
+
+// parseKey parses the value of the key
+func (p valueParser) parseKey(value string) (interface{}, error) {
+	if keyType, ok := p.options.Hints.KeyType(p.sectionName, p.keyName); ok {
+		return p.parseAs(value, keyType)
+	}
+	return p.guess(value), nil
+}
+
+// parseField parses the value of a container field of the key
+func (p valueParser) parseField(fieldName string, value string) (interface{}, error) {
+	if keyType, ok := p.options.Hints.FieldType(p.sectionName, p.keyName, fieldName); ok {
+		return p.parseAs(value, keyType)
+	}
+	return p.guess(value), nil
+}
+
+// guess returns the value as a string when RawStrings is set, else it guesses the type
+func (p valueParser) guess(value string) interface{} {
+	if isWrappedInParentheses(value) {
+		container, _ := p.parseContainer(value)
+		return container
+	}
+	if p.options.RawStrings {
+		return value
+	}
+	return toGuessedType(value)
+}
+
+// parseAs parses the value as the given type
+func (p valueParser) parseAs(value string, keyType KeyType) (interface{}, error) {
+	var result interface{}
+	var err error
+
+	switch keyType {
+	case String:
+		return value, nil
+	case Int:
+		var i int64
+		i, err = toInt64(value)
+		result = int(i)
+	case Float64:
+		result, err = toFloat64(value)
+	case Boolean:
+		result, err = toBool(value)
+	case Container:
+		if checkValueType(strings.TrimSpace(value)) != Container {
+			err = errors.New("value is not a container")
+		} else {
+			result, err = p.parseContainer(strings.TrimSpace(value))
+		}
+	default:
+		err = errors.New("unknown key type")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot parse %q as %s, %v", ErrTypeMismatch, value, keyType, err)
+	}
+	return result, nil
+}
+
+func (p valueParser) parseContainer(value string) (IniContainer, error) {
+	keyValues, err := deserializeToContainerKv(value, p)
+	if err != nil {
+		return IniContainer{}, err
+	}
+	return IniContainer{KeyValues: keyValues}, nil
+}
+
+//endregion
@@ -0,0 +1,231 @@
+package ini
+
+import "fmt"
+
+// History records the changes made to an IniFile through its mutation methods so they can be undone and redone.
+// Every call to a mutation method (e.g. OverwriteKey) is one entry, no matter how many keys it changes.
+// Changes made by modifying the exported fields directly, or values in place, are not recorded.
+type History struct {
+	file        *IniFile
+	maxDepth    int
+	undo        []historyEntry
+	redo        []historyEntry
+	dropped     int
+	checkpoints map[string]int
+	applying    bool
+	unsubscribe func()
+}
+
+type historyEntry struct {
+	op     uint64
+	events []ChangeEvent
+}
+
+// NewHistory starts recording the changes of file, at most maxDepth entries are kept (the oldest are dropped first), a maxDepth of 0 or less keeps everything
+func NewHistory(file *IniFile, maxDepth int) *History {
+	h := &History{
+		file:        file,
+		maxDepth:    maxDepth,
+		checkpoints: make(map[string]int),
+	}
+	h.unsubscribe = file.Subscribe(h.record)
+	return h
+}
+
+// Close stops recording changes
+func (h *History) Close() {
+	h.unsubscribe()
+}
+
+// CanUndo returns true if there is a change to undo
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo returns true if there is an undone change to redo
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Undo reverts the last recorded change
+func (h *History) Undo() error {
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+
+	entry := h.undo[len(h.undo)-1]
+	if err := h.apply(entry, true); err != nil {
+		return err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, entry)
+	return nil
+}
+
+// Redo applies the last undone change again
+func (h *History) Redo() error {
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+
+	entry := h.redo[len(h.redo)-1]
+	if err := h.apply(entry, false); err != nil {
+		return err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, entry)
+	return nil
+}
+
+// Checkpoint gives the current state of the file a name, an existing checkpoint with the same name is replaced
+func (h *History) Checkpoint(name string) {
+	h.checkpoints[name] = h.position()
+}
+
+// RestoreCheckpoint undoes or redoes changes until the file is in the state of the checkpoint
+func (h *History) RestoreCheckpoint(name string) error {
+	target, exists := h.checkpoints[name]
+	if !exists || target < h.dropped || target > h.position()+len(h.redo) {
+		delete(h.checkpoints, name)
+		return fmt.Errorf("%w: %q", ErrCheckpointNotFound, name)
+	}
+
+	for h.position() > target {
+		if err := h.Undo(); err != nil {
+			return err
+		}
+	}
+	for h.position() < target {
+		if err := h.Redo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear removes all entries and checkpoints
+func (h *History) Clear() {
+	h.undo = nil
+	h.redo = nil
+	h.dropped = 0
+	h.checkpoints = make(map[string]int)
+}
+
+// position returns the number of entries applied since the history was created or cleared
+func (h *History) position() int {
+	return h.dropped + len(h.undo)
+}
+
+// record is the observer that adds the events of the file to the history
+func (h *History) record(event ChangeEvent) {
+	if h.applying {
+		return
+	}
+	event.OldValue = cloneEventValue(event.OldValue)
+	event.NewValue = cloneEventValue(event.NewValue)
+
+	if len(h.undo) > 0 && h.undo[len(h.undo)-1].op == event.Op {
+		last := &h.undo[len(h.undo)-1]
+		last.events = append(last.events, event)
+		return
+	}
+
+	// A new change makes the undone changes and the checkpoints pointing to them unreachable
+	h.redo = nil
+	for name, position := range h.checkpoints {
+		if position > h.position() {
+			delete(h.checkpoints, name)
+		}
+	}
+
+	h.undo = append(h.undo, historyEntry{op: event.Op, events: []ChangeEvent{event}})
+	if h.maxDepth > 0 && len(h.undo) > h.maxDepth {
+		h.undo = h.undo[1:]
+		h.dropped++
+	}
+}
+
+// apply reverts the events of the entry in reverse order when undo is set, otherwise it applies them again in order.
+// If an event cannot be applied the events applied before it are rolled back, so the file is left as it was.
+func (h *History) apply(entry historyEntry, undo bool) error {
+	h.applying = true
+	defer func() { h.applying = false }()
+	defer h.file.beginOp()()
+
+	applied := make([]ChangeEvent, 0, len(entry.events))
+	for i := range entry.events {
+		event := entry.events[i]
+		if undo {
+			event = entry.events[len(entry.events)-1-i]
+		}
+		if err := h.applyEvent(event, undo); err != nil {
+			for j := len(applied) - 1; j >= 0; j-- {
+				// The file is in the state the applied events left it in, so reversing them can't fail
+				_ = h.applyEvent(applied[j], !undo)
+			}
+			return err
+		}
+		applied = append(applied, event)
+	}
+	return nil
+}
+
+func (h *History) applyEvent(event ChangeEvent, undo bool) error {
+	changeType := event.Type
+	value, raw := event.NewValue, event.newRaw
+	if undo {
+		value, raw = event.OldValue, event.oldRaw
+		switch changeType {
+		case SectionAdded:
+			changeType = SectionRemoved
+		case SectionRemoved:
+			changeType = SectionAdded
+		case KeyAdded:
+			changeType = KeyRemoved
+		case KeyRemoved:
+			changeType = KeyAdded
+		}
+	}
+
+	section := event.section
+	mismatch := fmt.Errorf("%w: cannot apply %s of section %q key %q at %d", ErrHistoryMismatch, changeType, event.Section, event.Key, event.Index)
+
+	switch changeType {
+	case SectionAdded:
+		if event.Index > len(h.file.Sections) {
+			return mismatch
+		}
+		h.file.insertSectionAt(event.Index, section)
+	case SectionRemoved:
+		if event.Index >= len(h.file.Sections) || h.file.Sections[event.Index] != section {
+			return mismatch
+		}
+		h.file.removeSectionAt(event.Index)
+	case KeyAdded:
+		if event.Index > len(section.Keys) {
+			return mismatch
+		}
+		key := NewIniKey(event.Key, cloneValue(value))
+		key.Raw = raw
+		section.insertKeyAt(event.Index, key)
+	case KeySet:
+		if event.Index >= len(section.Keys) || section.Keys[event.Index].Key != event.Key {
+			return mismatch
+		}
+		section.setKeyAt(event.Index, cloneValue(value), raw)
+	case KeyRemoved:
+		if event.Index >= len(section.Keys) || section.Keys[event.Index].Key != event.Key {
+			return mismatch
+		}
+		section.removeKeyAt(event.Index)
+	}
+	return nil
+}
+
+// cloneEventValue returns a deep copy of a key value, sections are kept as they are so they can be put back as the same section
+func cloneEventValue(value interface{}) interface{} {
+	if _, isSection := value.(*IniSection); isSection {
+		return value
+	}
+	return cloneValue(value)
+}
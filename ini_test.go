@@ -1,8 +1,13 @@
 package ini
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewIniFile(t *testing.T) {
@@ -94,3 +99,954 @@ key2=(key=(bob=bab),wow=22.1)`
 		t.Fail()
 	}
 }
+
+func TestDeserializeFromMapIsStable(t *testing.T) {
+	data := map[string]map[string][]string{
+		"b": {"z": {"1"}, "a": {"2"}, "m": {"3", "4"}},
+		"a": {"key": {"value"}},
+		"c": {"key": {"value"}},
+	}
+	expected := "[a]\nkey=value\n[b]\na=2\nm=3\nm=4\nz=1\n[c]\nkey=value\n"
+
+	for i := 0; i < 20; i++ {
+		if out := DeserializeFromMap(data).ToString(); out != expected {
+			t.Fatalf("unexpected order:\n%s", out)
+		}
+	}
+}
+
+func TestDeserializeFromMapWithReference(t *testing.T) {
+	reference, _ := DeserializeIniFile(`[ServerSettings]
+MaxPlayers=70
+DifficultyOffset=1
+[SessionSettings]
+SessionName=Test`)
+
+	data := ToMap(reference)
+	data["ServerSettings"]["AllowFlyerCarryPvE"] = []string{"True"}
+	data["MessageOfTheDay"] = map[string][]string{"Message": {"Hello"}}
+
+	expected := `[ServerSettings]
+MaxPlayers=70
+DifficultyOffset=1
+AllowFlyerCarryPvE=true
+[SessionSettings]
+SessionName=Test
+[MessageOfTheDay]
+Message=Hello
+`
+	if out := DeserializeFromMapWithReference(data, reference).ToString(); out != expected {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if out := DeserializeFromOrderedMap(ToOrderedMap(reference)).ToString(); out != reference.ToString() {
+		t.Errorf("ordered map round trip failed:\n%s", out)
+	}
+}
+
+func TestGet(t *testing.T) {
+	data := `[ServerSettings]
+MaxPlayers=70.0
+DifficultyOffset=0.5
+AllowFlyerCarryPvE=True
+ServerPassword=1234
+AutoSavePeriodMinutes=yes
+KickIdlePlayersPeriod=3600
+Mods=111
+Mods=222`
+
+	file, _ := DeserializeIniFile(data, "Mods")
+
+	if maxPlayers, err := Get(file, "ServerSettings", "MaxPlayers", 10); err != nil || maxPlayers != 70 {
+		t.Errorf("MaxPlayers: %v %v", maxPlayers, err)
+	}
+	if offset, err := Get(file, "ServerSettings", "DifficultyOffset", 1.0); err != nil || offset != 0.5 {
+		t.Errorf("DifficultyOffset: %v %v", offset, err)
+	}
+	if password, err := Get(file, "ServerSettings", "ServerPassword", ""); err != nil || password != "1234" {
+		t.Errorf("ServerPassword: %v %v", password, err)
+	}
+	if autoSave, err := Get(file, "ServerSettings", "AutoSavePeriodMinutes", false); err != nil || !autoSave {
+		t.Errorf("AutoSavePeriodMinutes: %v %v", autoSave, err)
+	}
+	if kick, err := Get(file, "ServerSettings", "KickIdlePlayersPeriod", time.Duration(0)); err != nil || kick != time.Hour {
+		t.Errorf("KickIdlePlayersPeriod: %v %v", kick, err)
+	}
+
+	if value, err := Get(file, "ServerSettings", "DifficultyOffset", 5); err == nil || value != 5 {
+		t.Errorf("expected conversion error and default, got %v %v", value, err)
+	}
+	if value, err := Get(file, "ServerSettings", "Missing", uint(3)); err == nil || value != 3 || !strings.Contains(err.Error(), `"Missing"`) {
+		t.Errorf("expected missing key error and default, got %v %v", value, err)
+	}
+
+	mods, err := GetAll[int64](file, "ServerSettings", "Mods")
+	if err != nil || len(mods) != 2 || mods[0] != 111 || mods[1] != 222 {
+		t.Errorf("Mods: %v %v", mods, err)
+	}
+
+	large, _ := DeserializeIniFile("[ServerSettings]\nLarge=1e20\nLimit=9223372036854775808\n")
+	if key, _ := large.GetKeyFromSection("ServerSettings", "Large"); key.Value != 1e20 {
+		t.Errorf("expected 1e20 to stay a float, got %#v", key.Value)
+	}
+	if value, err := Get(large, "ServerSettings", "Limit", int64(0)); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected 2^63 to overflow int64, got %v %v", value, err)
+	}
+}
+
+func TestDeserializeIniFileWithOptions(t *testing.T) {
+	data := `[SessionSettings]
+SessionName=123
+[ServerSettings]
+ServerPassword=True
+ServerAdminPassword=007
+MaxPlayers=70
+[/script/shootergame.shootergamemode]
+OverrideNamedEngramEntries=(EngramClassName=1e5,EngramPointsCost=3)`
+
+	raw, err := DeserializeIniFileWithOptions(data, ParseOptions{RawStrings: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := raw.GetKeyFromSection("ServerSettings", "ServerAdminPassword"); key.Value != "007" || key.Raw != "007" {
+		t.Errorf("expected raw string, got %#v", key.Value)
+	}
+	if key, _ := raw.GetKeyFromSection("ServerSettings", "MaxPlayers"); key.Value != "70" {
+		t.Errorf("expected raw string, got %#v", key.Value)
+	}
+	if raw.ToString() != data+"\n" {
+		t.Errorf("raw round trip failed:\n%s", raw.ToString())
+	}
+
+	hints := NewTypeHints().
+		SetKey("SessionSettings", "SessionName", String).
+		SetKey("", "ServerPassword", String).
+		SetKey("ServerSettings", "MaxPlayers", Int).
+		SetField("", "OverrideNamedEngramEntries", "EngramClassName", String)
+
+	typed, err := DeserializeIniFileWithOptions(data, ParseOptions{Hints: hints})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := typed.GetKeyFromSection("SessionSettings", "SessionName"); key.Value != "123" {
+		t.Errorf("expected string, got %#v", key.Value)
+	}
+	if key, _ := typed.GetKeyFromSection("ServerSettings", "ServerPassword"); key.Value != "True" {
+		t.Errorf("expected string, got %#v", key.Value)
+	}
+	if key, _ := typed.GetKeyFromSection("ServerSettings", "MaxPlayers"); key.Value != 70 || key.Raw != "70" {
+		t.Errorf("expected int, got %#v", key.Value)
+	}
+	key, _ := typed.GetKeyFromSection("/script/shootergame.shootergamemode", "OverrideNamedEngramEntries")
+	container, _ := key.AsContainer()
+	if field, _ := container.FindKey("EngramClassName"); field.Value != "1e5" {
+		t.Errorf("expected string field, got %#v", field.Value)
+	}
+	if field, _ := container.FindKey("EngramPointsCost"); field.Value != 3 {
+		t.Errorf("expected guessed int field, got %#v", field.Value)
+	}
+
+	if _, err := DeserializeIniFileWithOptions(data, ParseOptions{Hints: NewTypeHints().SetKey("", "SessionName", Boolean)}); err == nil {
+		t.Error("expected an error for a value that does not match its hint")
+	}
+
+	guessed, _ := DeserializeIniFile("[ServerSettings]\nPort=0012\nDifficultyOffset=1.50\nServerPVE=True\n")
+	if guessed.ToString() != "[ServerSettings]\nPort=0012\nDifficultyOffset=1.50\nServerPVE=True\n" {
+		t.Errorf("guessed values were rewritten:\n%s", guessed.ToString())
+	}
+	guessed.UpdateOrCreateKeyInSection("ServerSettings", "ServerPVE", false)
+	port, _ := guessed.GetKeyFromSection("ServerSettings", "Port")
+	port.Value = 13
+	if guessed.ToString() != "[ServerSettings]\nPort=13\nDifficultyOffset=1.50\nServerPVE=false\n" {
+		t.Errorf("changed values were not written:\n%s", guessed.ToString())
+	}
+
+	section, _ := guessed.GetSection("ServerSettings")
+	section.AddParsedKey("RCONPort=0027020")
+	section.AddOrReplaceParsedKey("DifficultyOffset=2.50")
+	section.AddOrReplaceParsedKey("MaxPlayers=070")
+	if guessed.ToString() != "[ServerSettings]\nPort=13\nDifficultyOffset=2.50\nServerPVE=false\nRCONPort=0027020\nMaxPlayers=070\n" {
+		t.Errorf("parsed keys were not written as they were parsed:\n%s", guessed.ToString())
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	file, _ := DeserializeIniFile(`[ServerSettings]
+MaxPlayers=70
+ServerPassword=secret`)
+
+	if _, err := file.GetKeyFromSection("Missing", "MaxPlayers"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("expected ErrSectionNotFound, got %v", err)
+	}
+	if _, err := file.GetKeyFromSection("ServerSettings", "Missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := file.GetKeyFromSectionWithMultipleValues("ServerSettings", "Missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	key, _ := file.GetKeyFromSection("ServerSettings", "ServerPassword")
+	_, err := key.AsInt()
+	var keyErr *KeyError
+	if !errors.Is(err, ErrTypeMismatch) || !errors.As(err, &keyErr) {
+		t.Fatalf("expected a *KeyError wrapping ErrTypeMismatch, got %v", err)
+	}
+	if keyErr.Key != "ServerPassword" || keyErr.Expected != Int || keyErr.Actual != String {
+		t.Errorf("unexpected key error %+v", keyErr)
+	}
+
+	_, err = Get(file, "ServerSettings", "ServerPassword", 0)
+	if !errors.As(err, &keyErr) || !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected a *KeyError wrapping ErrTypeMismatch, got %v", err)
+	}
+	if keyErr.Section != "ServerSettings" || keyErr.Key != "ServerPassword" || keyErr.Expected != Int || keyErr.Actual != String {
+		t.Errorf("unexpected key error %+v", keyErr)
+	}
+
+	section, _ := file.GetSection("ServerSettings")
+	if err := section.OverwriteKey("MaxPlayers"); !errors.Is(err, ErrNoValue) {
+		t.Errorf("expected ErrNoValue, got %v", err)
+	}
+}
+
+func TestSafeIniFile(t *testing.T) {
+	file, _ := DeserializeIniFile(`[ServerSettings]
+MaxPlayers=70`)
+	safe := NewSafeIniFile(file)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = safe.Update(func(tx *IniFile) error {
+					players, _ := Get(tx, "ServerSettings", "MaxPlayers", 0)
+					tx.UpdateOrCreateKeyInSection("ServerSettings", "MaxPlayers", players+1)
+					return nil
+				})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = safe.ToString()
+				snapshot := safe.Snapshot()
+				snapshot.UpdateOrCreateKeyInSection("ServerSettings", "MaxPlayers", -1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if key, err := safe.GetKeyFromSection("ServerSettings", "MaxPlayers"); err != nil || key.Value != 70+8*50 {
+		t.Errorf("unexpected MaxPlayers %v %v", key, err)
+	}
+
+	err := safe.Update(func(tx *IniFile) error {
+		tx.RemoveAllSections()
+		return ErrNoValue
+	})
+	if !errors.Is(err, ErrNoValue) {
+		t.Errorf("expected the error of the update, got %v", err)
+	}
+	if _, err := safe.GetKeyFromSection("ServerSettings", "MaxPlayers"); err != nil {
+		t.Errorf("failed update was not rolled back: %v", err)
+	}
+}
+
+func TestCloneAndEqual(t *testing.T) {
+	data := `[/script/shootergame.shootergamemode]
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_CryoGun_Mod_C",EngramHidden=True,Nested=(Cost=1,Level=2))
+[ServerSettings]
+DifficultyOffset=0.5`
+
+	original, _ := DeserializeIniFile(data, "OverrideNamedEngramEntries")
+	clone := original.Clone()
+	if !original.Equal(clone) {
+		t.Fatal("clone is not equal to the original")
+	}
+
+	clone.AllowedDuplicateKeys[0] = "Changed"
+	if original.AllowedDuplicateKeys[0] != "OverrideNamedEngramEntries" {
+		t.Error("AllowedDuplicateKeys is shared with the clone")
+	}
+	if !clone.Sections[0].IsAllowedDuplicateKey("Changed") {
+		t.Error("cloned sections do not use the AllowedDuplicateKeys of the cloned file")
+	}
+
+	key, _ := clone.GetKeyFromSection("/script/shootergame.shootergamemode", "OverrideNamedEngramEntries")
+	container, _ := key.AsContainer()
+	nested, _ := container.FindKey("Nested")
+	nested.Value.(IniContainer).KeyValues[0].Value = 5
+	if original.Equal(clone) {
+		t.Error("nested container values are shared with the clone")
+	}
+
+	sectionClone := original.Sections[0].Clone()
+	*sectionClone.AllowedDuplicateKeys = nil
+	if len(original.AllowedDuplicateKeys) != 1 {
+		t.Error("AllowedDuplicateKeys is shared with the section clone")
+	}
+
+	other, _ := DeserializeIniFile(`[serversettings]
+DifficultyOffset=0.50001
+[/script/shootergame.shootergamemode]
+OverrideNamedEngramEntries=(EngramHidden=true,EngramClassName="engramentry_cryogun_mod_c",Nested=(Level=2,Cost=1.0))`)
+
+	if original.Equal(other) {
+		t.Error("files with a different order should not be equal")
+	}
+	options := EqualOptions{IgnoreOrder: true, IgnoreCase: true, NumericTolerance: 0.001}
+	if !original.EqualWithOptions(other, options) {
+		t.Error("files should be equal when ignoring order, case and small numeric differences")
+	}
+	options.NumericTolerance = 0
+	if original.EqualWithOptions(other, options) {
+		t.Error("files should not be equal without numeric tolerance")
+	}
+}
+
+func TestChangeEvents(t *testing.T) {
+	file, _ := DeserializeIniFile(`[ServerSettings]
+MaxPlayers=70
+Mods=1
+Mods=2`, "Mods")
+
+	var events []ChangeEvent
+	unsubscribe := file.Subscribe(func(event ChangeEvent) {
+		events = append(events, event)
+	})
+
+	file.UpdateOrCreateKeyInSection("ServerSettings", "MaxPlayers", 20)
+	file.AddKeyToSection("SessionSettings", "SessionName", "Test")
+	section, _ := file.GetSection("ServerSettings")
+	_ = section.OverwriteKey("Mods", "3")
+	file.RemoveSection("SessionSettings")
+	if section.InsertKeyAt(3, "Mods", "4") || !section.InsertKeyAt(1, "Mods", "2") {
+		t.Error("unexpected InsertKeyAt result")
+	}
+
+	expected := []ChangeEvent{
+		{Type: KeySet, Op: 1, Section: "ServerSettings", Key: "MaxPlayers", Index: 0, OldValue: 70, NewValue: 20},
+		{Type: SectionAdded, Op: 2, Section: "SessionSettings", Index: 1},
+		{Type: KeyAdded, Op: 2, Section: "SessionSettings", Key: "SessionName", Index: 0, NewValue: "Test"},
+		{Type: KeyRemoved, Op: 3, Section: "ServerSettings", Key: "Mods", Index: 2, OldValue: 2},
+		{Type: KeyRemoved, Op: 3, Section: "ServerSettings", Key: "Mods", Index: 1, OldValue: 1},
+		{Type: KeyAdded, Op: 3, Section: "ServerSettings", Key: "Mods", Index: 1, NewValue: "3"},
+		{Type: SectionRemoved, Op: 4, Section: "SessionSettings", Index: 1},
+		{Type: KeyAdded, Op: 5, Section: "ServerSettings", Key: "Mods", Index: 1, NewValue: "2"},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, event := range events {
+		if event.Type == SectionAdded || event.Type == SectionRemoved {
+			event.NewValue, event.OldValue = nil, nil
+		}
+		event.section, event.oldRaw, event.newRaw = nil, "", ""
+		if event != expected[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, expected[i], event)
+		}
+	}
+
+	unsubscribe()
+	file.RemoveAllSections()
+	if len(events) != len(expected) {
+		t.Error("observer was called after unsubscribing")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	data := `[ServerSettings]
+MaxPlayers=70
+Mods=1
+DifficultyOffset=1
+Mods=2
+[SessionSettings]
+SessionName=Test
+`
+	file, _ := DeserializeIniFile(data, "Mods")
+	history := NewHistory(file, 0)
+
+	file.RemoveKeyFromSection("ServerSettings", "MaxPlayers")
+	file.UpdateOrCreateKeyInSection("ServerSettings", "DifficultyOffset", 0.5)
+	history.Checkpoint("edited")
+	section, _ := file.GetSection("ServerSettings")
+	_ = section.OverwriteKey("Mods", "3", "4")
+	file.RemoveSection("SessionSettings")
+	afterEdits := file.ToString()
+
+	for history.CanUndo() {
+		if err := history.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if file.ToString() != data {
+		t.Errorf("undo did not restore the original file:\n%s", file.ToString())
+	}
+
+	for history.CanRedo() {
+		if err := history.Redo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if file.ToString() != afterEdits {
+		t.Errorf("redo did not restore the edited file:\n%s", file.ToString())
+	}
+
+	if err := history.RestoreCheckpoint("edited"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := Get(file, "ServerSettings", "DifficultyOffset", 0.0); value != 0.5 {
+		t.Errorf("unexpected DifficultyOffset %v", value)
+	}
+	if mods, _ := GetAll[int](file, "ServerSettings", "Mods"); len(mods) != 2 || mods[0] != 1 || mods[1] != 2 {
+		t.Errorf("unexpected Mods %v", mods)
+	}
+
+	file.AddKeyToSection("ServerSettings", "New", 1)
+	if history.CanRedo() {
+		t.Error("a new change should clear the redo entries")
+	}
+	if err := history.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("expected ErrNothingToRedo, got %v", err)
+	}
+
+	atomic, _ := DeserializeIniFile("[ServerSettings]\nMods=1\nPort=07777\nMods=2\n", "Mods")
+	atomicHistory := NewHistory(atomic, 0)
+	atomic.RemoveKeyFromSection("ServerSettings", "Port")
+	if err := atomicHistory.Undo(); err != nil || atomic.ToString() != "[ServerSettings]\nMods=1\nPort=07777\nMods=2\n" {
+		t.Errorf("undo did not restore the removed key as written: %v\n%s", err, atomic.ToString())
+	}
+	atomicSection, _ := atomic.GetSection("ServerSettings")
+	atomic.UpdateOrCreateKeyInSection("ServerSettings", "Port", 12)
+	atomicSection.AddParsedKey("DifficultyOffset=1.50")
+	if err := atomicHistory.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := atomicHistory.Undo(); err != nil || atomic.ToString() != "[ServerSettings]\nMods=1\nPort=07777\nMods=2\n" {
+		t.Errorf("undo did not restore the changed key as written: %v\n%s", err, atomic.ToString())
+	}
+	_ = atomicHistory.Redo()
+	_ = atomicHistory.Redo()
+	if atomic.ToString() != "[ServerSettings]\nMods=1\nPort=12\nMods=2\nDifficultyOffset=1.50\n" {
+		t.Errorf("redo did not add the parsed key as written:\n%s", atomic.ToString())
+	}
+	atomic.RemoveMultipleKeysFromSection("ServerSettings", "Mods")
+	atomicSection.Keys = nil
+	if err := atomicHistory.Undo(); !errors.Is(err, ErrHistoryMismatch) {
+		t.Errorf("expected ErrHistoryMismatch, got %v", err)
+	}
+	if len(atomicSection.Keys) != 0 || !atomicHistory.CanUndo() {
+		t.Errorf("a failed undo changed the file or the history: %v", atomic.ToString())
+	}
+
+	bounded := NewHistory(NewIniFile(), 2)
+	bounded.Checkpoint("start")
+	for i := 0; i < 3; i++ {
+		bounded.file.AddKeyToSection("ServerSettings", "Key", i)
+	}
+	if err := bounded.RestoreCheckpoint("start"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("expected ErrCheckpointNotFound, got %v", err)
+	}
+	if err := bounded.Undo(); err != nil {
+		t.Error(err)
+	}
+	if err := bounded.Undo(); err != nil {
+		t.Error(err)
+	}
+	if err := bounded.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	data := `[/script/shootergame.shootergamemode]
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_CryoGun_Mod_C",EngramHidden=True,EngramPointsCost=0)
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_Campfire_C",EngramHidden=False,EngramPointsCost=3)
+OverrideNamedEngramEntries=(EngramClassName="EngramEntry_CryoGun_Mod_C",EngramPointsCost=7)
+[ServerSettings]
+Mods=1
+Mods=2`
+	file, _ := DeserializeIniFile(data, "OverrideNamedEngramEntries", "Mods")
+
+	costs, err := QueryAs[int](file, `"/script/shootergame.shootergamemode"/OverrideNamedEngramEntries[EngramClassName="EngramEntry_CryoGun_Mod_C"]/EngramPointsCost`)
+	if err != nil || len(costs) != 2 || costs[0] != 0 || costs[1] != 7 {
+		t.Errorf("unexpected costs %v %v", costs, err)
+	}
+
+	results, err := file.Query(`*/OverrideNamedEngramEntries[EngramHidden!=true]/EngramClassName`)
+	if err != nil || len(results) != 2 || results[0].Value != `"EngramEntry_Campfire_C"` || results[0].Field != "EngramClassName" {
+		t.Errorf("unexpected results %+v %v", results, err)
+	}
+
+	if mods, err := QueryAs[int](file, "ServerSettings/Mods[1]"); err != nil || len(mods) != 1 || mods[0] != 2 {
+		t.Errorf("unexpected mods %v %v", mods, err)
+	}
+
+	history := NewHistory(file, 0)
+	count, err := file.SetPath(`*/OverrideNamedEngramEntries[EngramClassName="EngramEntry_CryoGun_Mod_C"]/EngramPointsCost`, 5)
+	if err != nil || count != 2 {
+		t.Fatalf("unexpected set result %v %v", count, err)
+	}
+	if costs, _ := QueryAs[int](file, `*/OverrideNamedEngramEntries/EngramPointsCost`); len(costs) != 3 || costs[0] != 5 || costs[1] != 3 || costs[2] != 5 {
+		t.Errorf("unexpected costs after set %v", costs)
+	}
+
+	count, err = file.DeletePath(`*/OverrideNamedEngramEntries/EngramHidden`)
+	if err != nil || count != 2 {
+		t.Fatalf("unexpected delete result %v %v", count, err)
+	}
+	if count, _ := file.DeletePath(`ServerSettings/Mods`); count != 2 {
+		t.Errorf("expected 2 deleted keys, got %d", count)
+	}
+	if results, _ := file.Query(`*/OverrideNamedEngramEntries/EngramHidden`); len(results) != 0 {
+		t.Errorf("fields were not deleted %+v", results)
+	}
+
+	for history.CanUndo() {
+		_ = history.Undo()
+	}
+	if file.ToString() != data+"\n" {
+		t.Errorf("undo did not restore the file:\n%s", file.ToString())
+	}
+
+	for _, path := range []string{"ServerSettings", `"unterminated/Key`, "Section/Key[", "Section[Field=1]/Key", "Section/Key[x]/"} {
+		if _, err := file.Query(path); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("expected ErrInvalidPath for %q, got %v", path, err)
+		}
+	}
+}
+
+func TestContainerEditing(t *testing.T) {
+	file, _ := DeserializeIniFile(`[/script/shootergame.shootergamemode]
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=100,bIgnoreMultiplier=true))`)
+	section := file.Sections[0]
+	key := section.Keys[0]
+
+	err := key.EditContainer(func(container *IniContainer) error {
+		if err := container.Set("Quantity.MaxItemQuantity", 1000); err != nil {
+			return err
+		}
+		if err := container.Insert("Quantity.Comment", 0, "stone"); err != nil {
+			return err
+		}
+		if err := container.Remove("Quantity.bIgnoreMultiplier"); err != nil {
+			return err
+		}
+		if err := container.Rename("ItemClassString", "ItemClass"); err != nil {
+			return err
+		}
+		return container.Set("Extra.Level", 2)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `ConfigOverrideItemMaxQuantity=(ItemClass="PrimalItemResource_Stone_C",Quantity=(Comment=stone,MaxItemQuantity=1000),Extra=(Level=2))`
+	if key.ToString() != expected {
+		t.Errorf("unexpected key:\n%s", key.ToString())
+	}
+
+	container, _ := key.AsContainer()
+	if field, ok := container.Get("Quantity.MaxItemQuantity"); !ok || field.Value != 1000 {
+		t.Errorf("unexpected field %v", field)
+	}
+	if _, ok := container.Get("Quantity.Missing"); ok {
+		t.Error("missing field was found")
+	}
+	if err := container.Rename("Missing.Field", "Other"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := container.Set("ItemClass.Field", 1); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %v", err)
+	}
+	if err := container.Remove("Quantity.Missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	err = key.EditContainer(func(container *IniContainer) error {
+		if err := container.Remove("Quantity"); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil || key.ToString() != expected {
+		t.Errorf("a failed edit changed the key:\n%s", key.ToString())
+	}
+
+	history := NewHistory(file, 0)
+	err = section.EditContainerKey("ConfigOverrideItemMaxQuantity", func(container *IniContainer) error {
+		return container.Set("Quantity.MaxItemQuantity", 5)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := QueryAs[int](file, "*/ConfigOverrideItemMaxQuantity/Quantity/MaxItemQuantity"); len(value) != 1 || value[0] != 5 {
+		t.Errorf("unexpected value %v", value)
+	}
+	_ = history.Undo()
+	if key := section.Keys[0]; key.ToString() != expected {
+		t.Errorf("undo did not restore the container:\n%s", key.ToString())
+	}
+}
+
+func TestNestedContainers(t *testing.T) {
+	data := `[/script/shootergame.shootergamemode]
+ConfigOverrideSupplyCrateItems=(SupplyCrateClassString="SupplyCrate_Level03_C",ItemSets=((SetWeight=1.0,ItemEntries=((EntryWeight=0.5,ItemClassStrings=("PrimalItem_A_C","PrimalItem_B_C"),ItemsWeights=(1.0,0.5)))),(SetWeight=0.25,ItemEntries=())))
+Empty=()
+Name="Foo (bar), baz"`
+
+	file, _ := DeserializeIniFile(data)
+	key, _ := file.GetKeyFromSection("/script/shootergame.shootergamemode", "ConfigOverrideSupplyCrateItems")
+	container, err := key.AsContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itemSets, ok := container.Get("ItemSets")
+	if !ok {
+		t.Fatal("ItemSets not found")
+	}
+	sets, ok := itemSets.Value.(IniContainer)
+	if !ok || len(sets.KeyValues) != 2 || sets.KeyValues[0].Key != "" {
+		t.Fatalf("ItemSets is not a list of containers: %#v", itemSets.Value)
+	}
+	firstSet, _ := sets.KeyValues[0].AsContainer()
+	if weight, _ := firstSet.Get("SetWeight"); weight.Value != 1 {
+		t.Errorf("unexpected SetWeight %#v", weight.Value)
+	}
+
+	items, _ := QueryAs[string](file, `*/ConfigOverrideSupplyCrateItems/ItemSets/*/ItemEntries/*/ItemClassStrings/*`)
+	if len(items) != 2 || items[0] != `"PrimalItem_A_C"` || items[1] != `"PrimalItem_B_C"` {
+		t.Errorf("unexpected items %v", items)
+	}
+
+	if name, _ := Get(file, "/script/shootergame.shootergamemode", "Name", ""); name != `"Foo (bar), baz"` {
+		t.Errorf("unexpected Name %q", name)
+	}
+
+	if strings.TrimSpace(file.ToString()) != data {
+		t.Errorf("unexpected output:\n%s", file.ToString())
+	}
+
+	if unbalanced, err := DeserializeIniFile("[ServerSettings]\nKey=(A=(B=1)\n"); err != nil || len(unbalanced.Sections[0].Keys) != 1 {
+		t.Errorf("unbalanced parentheses were not parsed: %v", err)
+	}
+
+	legacy := NewIniContainerFromSlice([]ContainerKey{{Key: "Quantity", Value: []ContainerKey{{Key: "MaxItemQuantity", Value: 10}}}})
+	if _, ok := legacy.KeyValues[0].Value.(IniContainer); !ok {
+		t.Errorf("nested slice was not normalized: %#v", legacy.KeyValues[0].Value)
+	}
+	field := ContainerKey{Key: "Quantity", Value: []ContainerKey{{Key: "MaxItemQuantity", Value: 10}}}
+	if field.ToString() != "Quantity=(MaxItemQuantity=10)" {
+		t.Errorf("unexpected legacy output %s", field.ToString())
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	file, _ := DeserializeIniFile(`[ServerSettings]
+MaxPlayers=70
+DifficultyOffset=1
+ServerPVE=False
+[/Script/ShooterGame.ShooterGameMode]
+OverridePlayerLevelEngramPoints=5
+OverridePlayerLevelEngramPoints=8
+`, "OverridePlayerLevelEngramPoints")
+
+	environ := []string{
+		"PATH=/usr/bin",
+		"ARK_SERVERSETTINGS__MAXPLAYERS=70",
+		"ARK_SERVERSETTINGS__DIFFICULTYOFFSET=0.5",
+		"ark_serversettings__serverpve=yes",
+		"ARK_SCRIPT_SHOOTERGAME_SHOOTERGAMEMODE__OverridePlayerLevelEngramPoints__1=10",
+		"ARK_SCRIPT_SHOOTERGAME_SHOOTERGAMEMODE__OverridePlayerLevelEngramPoints__2=12",
+		"ARK_SessionSettings__SessionName=My Server",
+		"ARK_SCRIPT_SHOOTERGAME_SHOOTERGAMEMODE__OverridePlayerLevelEngramPoints=1",
+		"ARK_SERVERSETTINGS__MAXPLAYERS__5=1",
+		"ARK_UNKNOWN__KEY=1",
+	}
+
+	changes := planEnv(file, "ARK", environ)
+	if len(changes) != 8 {
+		t.Fatalf("expected 8 changes, got %+v", changes)
+	}
+	if changes[0].Variable != "ARK_SCRIPT_SHOOTERGAME_SHOOTERGAMEMODE__OverridePlayerLevelEngramPoints" || !errors.Is(changes[0].Err, ErrInvalidEnv) {
+		t.Errorf("expected an error for a duplicate key without index, got %+v", changes[0])
+	}
+	if change := changes[4]; change.Section != "ServerSettings" || change.Key != "MaxPlayers" || !errors.Is(change.Err, ErrKeyNotFound) {
+		t.Errorf("expected an error for an index out of range, got %+v", change)
+	}
+	if !errors.Is(changes[6].Err, ErrSectionNotFound) {
+		t.Errorf("expected an error for an unknown section, got %+v", changes[6])
+	}
+	if changes[3].OldValue != 1 || changes[3].NewValue != 0.5 {
+		t.Errorf("unexpected DifficultyOffset change %+v", changes[3])
+	}
+
+	_, err := applyEnv(file, "ARK", environ)
+	if !errors.Is(err, ErrInvalidEnv) || !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("expected the errors to be joined, got %v", err)
+	}
+	expected := `[ServerSettings]
+MaxPlayers=70
+DifficultyOffset=0.5
+ServerPVE=true
+[/Script/ShooterGame.ShooterGameMode]
+OverridePlayerLevelEngramPoints=5
+OverridePlayerLevelEngramPoints=10
+OverridePlayerLevelEngramPoints=12
+[SessionSettings]
+SessionName=My Server
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+
+	file, _ = DeserializeIniFile("[ServerSettings]\nDifficultyOffset=1\n")
+	if _, err := applyEnv(file, "ARKINI_GameUserSettings", []string{"ARKINI_GameUserSettings_ServerSettings_DifficultyOffset=2", "ARKINI_GameUserSettings_ServerSettings_Max_Players=10"}); err != nil {
+		t.Fatal(err)
+	}
+	if output := file.ToString(); output != "[ServerSettings]\nDifficultyOffset=2\nMax_Players=10\n" {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("server.ini", `[SessionSettings]
+SessionName={{.Cluster}}-{{.Map}}
+[ServerSettings]
+MaxPlayers={{.MaxPlayers}}
+{{- if .PvE}}
+ServerPVE=true
+{{- end}}
+{{- range .Mods}}
+ModId={{.}}
+{{- end}}
+{{if .Admin}}[MessageOfTheDay]
+Message={{quote .Admin}}
+{{end}}`, "ModId")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{"Cluster": "eu", "Map": "TheIsland", "MaxPlayers": 70, "PvE": true, "Mods": []int{1, 2}, "Admin": ""}
+	file, err := tmpl.Execute(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[SessionSettings]
+SessionName=eu-TheIsland
+[ServerSettings]
+MaxPlayers=70
+ServerPVE=true
+ModId=1
+ModId=2
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+
+	delete(data, "Map")
+	_, err = tmpl.Execute(data)
+	var templateErr *TemplateError
+	if !errors.As(err, &templateErr) || templateErr.Line != 2 || templateErr.Column != 27 || templateErr.Rendered {
+		t.Errorf("expected an error at 2:27, got %#v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "server.ini:2:27: executing") {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+
+	if _, err := ParseTemplate("broken.ini", "[ServerSettings]\nMaxPlayers={{.MaxPlayers"); !errors.As(err, &templateErr) || templateErr.Line != 2 {
+		t.Errorf("expected a parse error on line 2, got %v", err)
+	}
+
+	tmpl, _ = ParseTemplate("typed.ini", "[ServerSettings]\nMaxPlayers={{.}}\n")
+	hints := NewTypeHints().SetKey("ServerSettings", "MaxPlayers", Int)
+	_, err = tmpl.ExecuteWithOptions("many", ParseOptions{Hints: hints})
+	if !errors.As(err, &templateErr) || !templateErr.Rendered || templateErr.Line != 2 || !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected a type error on rendered line 2, got %v", err)
+	}
+}
+
+func TestSaveFileAndDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Game.ini")
+	old, _ := DeserializeIniFile(`[ServerSettings]
+MaxPlayers=70
+ActiveMods=1
+[Removed]
+Key=1
+[/Script/ShooterGame.ShooterGameMode]
+OverridePlayerLevelEngramPoints=5
+OverridePlayerLevelEngramPoints=8
+`, "OverridePlayerLevelEngramPoints")
+	if err := SaveFile(path, old); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(path, "OverridePlayerLevelEngramPoints")
+	if err != nil || !loaded.Equal(old) {
+		t.Fatalf("loaded file differs from the saved file: %v", err)
+	}
+	if _, err := LoadFile(filepath.Join(filepath.Dir(path), "missing.ini")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+
+	loaded.UpdateOrCreateKeyInSection("ServerSettings", "MaxPlayers", "70")
+	loaded.UpdateOrCreateKeyInSection("ServerSettings", "ActiveMods", "1,2")
+	loaded.RemoveSection("Removed")
+	loaded.AddKeyToSection("Added", "Key", 1)
+	loaded.RemoveKeyFromSection("/Script/ShooterGame.ShooterGameMode", "OverridePlayerLevelEngramPoints")
+
+	differences := Diff(old, loaded)
+	expected := []Difference{
+		{Type: KeySet, Section: "ServerSettings", Key: "ActiveMods", OldValue: 1, NewValue: "1,2"},
+		{Type: SectionRemoved, Section: "Removed"},
+		{Type: KeySet, Section: "/Script/ShooterGame.ShooterGameMode", Key: "OverridePlayerLevelEngramPoints", OldValue: 5, NewValue: 8},
+		{Type: KeyRemoved, Section: "/Script/ShooterGame.ShooterGameMode", Key: "OverridePlayerLevelEngramPoints", Index: 1, OldValue: 8},
+		{Type: SectionAdded, Section: "Added"},
+	}
+	if len(differences) != len(expected) {
+		t.Fatalf("unexpected differences %+v", differences)
+	}
+	for i := range expected {
+		if differences[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], differences[i])
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GameUserSettings.ini")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("[ServerSettings]\nMaxPlayers=70\n")
+
+	// The ticker never fires, the test polls with its own clock
+	var events []WatchEvent
+	watcher, err := WatchWithOptions(path, WatchOptions{Interval: time.Hour, Debounce: 50 * time.Millisecond}, func(event WatchEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	now := time.Now()
+	poll := func(after time.Duration) {
+		now = now.Add(after)
+		watcher.poll(now)
+	}
+
+	for _, players := range []string{"10", "20", "80"} {
+		write("[ServerSettings]\nMaxPlayers=" + players + "\n")
+		poll(30 * time.Millisecond)
+	}
+	if poll(30 * time.Millisecond); len(events) != 0 {
+		t.Fatalf("expected no event before the debounce time, got %+v", events)
+	}
+	poll(30 * time.Millisecond)
+	expected := Difference{Type: KeySet, Section: "ServerSettings", Key: "MaxPlayers", OldValue: 70, NewValue: 80}
+	if len(events) != 1 || events[0].Err != nil || len(events[0].Differences) != 1 || events[0].Differences[0] != expected {
+		t.Fatalf("expected one event for the burst of writes, got %+v", events)
+	}
+
+	// A rewrite of the same size that keeps the modification time, like on file systems with a coarse clock
+	info, _ := os.Stat(path)
+	write("[ServerSettings]\nMaxPlayers=20\n")
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	poll(time.Millisecond)
+	poll(time.Second)
+	if len(events) != 2 || events[1].Differences[0].NewValue != 20 {
+		t.Fatalf("expected the same size rewrite to be found, got %+v", events)
+	}
+
+	write("; players\n[ServerSettings]\nMaxPlayers=20.0\n")
+	poll(time.Millisecond)
+	poll(time.Second)
+	if len(events) != 2 {
+		t.Errorf("expected no event for a formatting change, got %+v", events[2:])
+	}
+
+	file := watcher.File().Clone()
+	file.UpdateOrCreateKeyInSection("ServerSettings", "MaxPlayers", 90)
+	if err := SaveFile(path, file); err != nil {
+		t.Fatal(err)
+	}
+	poll(time.Millisecond)
+	poll(time.Second)
+	if len(events) != 2 {
+		t.Errorf("expected no event for an own save, got %+v", events[2:])
+	}
+	section, _ := watcher.File().GetSection("ServerSettings")
+	if key, _ := section.GetKey("MaxPlayers"); key == nil || key.ToValueString() != "90" {
+		t.Errorf("expected the own save to be loaded, got %v", key)
+	}
+
+	// Old writes are forgotten when the next one is recorded
+	ownWrites.mu.Lock()
+	ownWrites.writes[absolutePath(path)] = ownWrite{hash: watcher.content, time: time.Now().Add(-2 * ownWriteLifetime)}
+	ownWrites.mu.Unlock()
+	ownWrites.record(path+".other", nil)
+	if ownWrites.saved(path, watcher.content) {
+		t.Error("expected the old write to be forgotten")
+	}
+}
+
+func TestHashAndSaveFileIfUnchanged(t *testing.T) {
+	file, _ := DeserializeIniFile("[ServerSettings]\nMaxPlayers=70\nServerPVE=True\nDifficultyOffset=1.0\n")
+	same, _ := DeserializeIniFile("; comment\n\n[ServerSettings]\n  MaxPlayers=70\nServerPVE=true\nDifficultyOffset=1\n")
+	if file.Hash() != same.Hash() {
+		t.Error("expected whitespace, comments and formatting to keep the hash")
+	}
+	changed := file.Clone()
+	changed.UpdateOrCreateKeyInSection("ServerSettings", "MaxPlayers", 71)
+	if changed.Hash() == file.Hash() {
+		t.Error("expected a changed value to change the hash")
+	}
+	if moved, _ := DeserializeIniFile("[ServerSetting]\nMaxPlayers=70\nServerPVE=True\nDifficultyOffset=1.0\n"); moved.Hash() == file.Hash() {
+		t.Error("expected a renamed section to change the hash")
+	}
+
+	path := filepath.Join(t.TempDir(), "GameUserSettings.ini")
+	if err := SaveFileIfUnchanged(path, file, "stale"); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for a missing file, got %v", err)
+	}
+	if err := SaveFileIfUnchanged(path, file, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFileIfUnchanged(path, file, ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for an existing file, got %v", err)
+	}
+
+	loaded, _ := LoadFile(path)
+	expected := loaded.Hash()
+	if err := os.WriteFile(path, []byte("; edited by hand\n[ServerSettings]\nMaxPlayers=70\nServerPVE=true\nDifficultyOffset=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFileIfUnchanged(path, changed, expected); err != nil {
+		t.Errorf("expected a formatting change to be no conflict, got %v", err)
+	}
+	if err := SaveFileIfUnchanged(path, file, expected); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict after the file changed, got %v", err)
+	}
+	if loaded, _ := LoadFile(path); !loaded.Equal(changed) {
+		t.Errorf("the conflicting save was written:\n%s", loaded.ToString())
+	}
+
+	data, _ := os.ReadFile(path)
+	hints := NewTypeHints().SetKey("", "MaxPlayers", String)
+	for _, options := range []ParseOptions{{RawStrings: true}, {Hints: hints}} {
+		raw, _ := DeserializeIniFileWithOptions(string(data), options)
+		if raw.Hash() != changed.Hash() {
+			t.Errorf("expected the hash to not depend on %+v", options)
+		}
+		if err := SaveFileIfUnchanged(path, raw, raw.Hash()); err != nil {
+			t.Errorf("expected no conflict for a file parsed with %+v, got %v", options, err)
+		}
+	}
+}
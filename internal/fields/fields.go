@@ -0,0 +1,402 @@
+// Package fields contains helpers to read and write the fields of ARK container values
+package fields
+
+import (
+	"fmt"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+//region Sections
+
+// GameModeSection is the Game.ini section holding the game mode overrides, it is written in the case the game uses
+const GameModeSection = "/Script/ShooterGame.ShooterGameMode"
+
+// FindSection returns the first section with the given name ignoring case, ARK writes section names in different cases
+func FindSection(file *ini.IniFile, sectionName string) (*ini.IniSection, bool) {
+	for _, section := range file.Sections {
+		if strings.EqualFold(section.SectionName, sectionName) {
+			return section, true
+		}
+	}
+	return nil, false
+}
+
+// GetOrCreateSection returns the section with the given name ignoring case, or creates it
+func GetOrCreateSection(file *ini.IniFile, sectionName string) *ini.IniSection {
+	if section, exists := FindSection(file, sectionName); exists {
+		return section
+	}
+	return file.GetOrCreateSection(sectionName)
+}
+
+// KeyName returns the name in keyNames that matches name ignoring case, or an empty string if none does, the game reads key names case-insensitively
+func KeyName(name string, keyNames ...string) string {
+	for _, keyName := range keyNames {
+		if strings.EqualFold(name, keyName) {
+			return keyName
+		}
+	}
+	return ""
+}
+
+// FindKey returns the first key in the section that matches keyName ignoring case
+func FindKey(section *ini.IniSection, keyName string) (*ini.IniKey, bool) {
+	for _, key := range section.Keys {
+		if strings.EqualFold(key.Key, keyName) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// FindKeys returns the keys in the section that match keyName ignoring case
+func FindKeys(section *ini.IniSection, keyName string) []*ini.IniKey {
+	var keys []*ini.IniKey
+	for _, key := range section.Keys {
+		if strings.EqualFold(key.Key, keyName) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// AllowDuplicates adds the keys to the allowed duplicate keys of the file if they are missing
+func AllowDuplicates(file *ini.IniFile, keyNames ...string) {
+	for _, keyName := range keyNames {
+		found := false
+		for _, allowed := range file.AllowedDuplicateKeys {
+			if allowed == keyName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			file.AllowedDuplicateKeys = append(file.AllowedDuplicateKeys, keyName)
+		}
+	}
+}
+
+// ReplaceKeys replaces all keys with the given name in the section by one key per value.
+// The values take the places of the existing keys in order, keys that already have their value are kept as they are,
+// extra values are inserted after the last existing key and are only appended to the section if there was none.
+func ReplaceKeys[T any](section *ini.IniSection, keyName string, values []T) {
+	replaceKeys(section, keyName, values, func(name string) bool {
+		return name == keyName
+	})
+}
+
+// ReplaceKeysIgnoringCase is ReplaceKeys matching the key names ignoring case like the game does, unchanged keys keep the case they are written in
+func ReplaceKeysIgnoringCase[T any](section *ini.IniSection, keyName string, values []T) {
+	replaceKeys(section, keyName, values, func(name string) bool {
+		return strings.EqualFold(name, keyName)
+	})
+}
+
+func replaceKeys[T any](section *ini.IniSection, keyName string, values []T, matches func(name string) bool) {
+	var positions []int
+	for i, key := range section.Keys {
+		if matches(key.Key) {
+			positions = append(positions, i)
+		}
+	}
+
+	for i := len(positions) - 1; i >= len(values); i-- {
+		section.RemoveKeyAt(positions[i])
+	}
+	for i, value := range values {
+		if i >= len(positions) {
+			if len(positions) == 0 {
+				section.AddKey(keyName, value)
+			} else {
+				section.InsertKeyAt(positions[len(positions)-1]+1+i-len(positions), keyName, value)
+			}
+			continue
+		}
+		existing := section.Keys[positions[i]]
+		if existing.Equal(ini.NewIniKey(existing.Key, value)) {
+			continue
+		}
+		section.RemoveKeyAt(positions[i])
+		section.InsertKeyAt(positions[i], keyName, value)
+	}
+}
+
+//endregion
+
+//region Reading
+
+// Present returns the names of the fields of the container, see AddUnlessDefault
+func Present(container ini.IniContainer) map[string]bool {
+	present := make(map[string]bool, len(container.KeyValues))
+	for _, field := range container.KeyValues {
+		present[field.Key] = true
+	}
+	return present
+}
+
+// Has returns true if the container has the field
+func Has(container ini.IniContainer, name string) bool {
+	_, exists := container.FindKey(name)
+	return exists
+}
+
+// String returns the field as a string without the surrounding quotes, or an empty string if it doesn't exist
+func String(container ini.IniContainer, name string) string {
+	field, exists := container.FindKey(name)
+	if !exists {
+		return ""
+	}
+	return Unquote(field.ToValueString())
+}
+
+// Int returns the field as an int, or defaultValue if it doesn't exist
+func Int(container ini.IniContainer, name string, defaultValue int) (int, error) {
+	return get(container, name, defaultValue)
+}
+
+// Float returns the field as a float64, or defaultValue if it doesn't exist
+func Float(container ini.IniContainer, name string, defaultValue float64) (float64, error) {
+	return get(container, name, defaultValue)
+}
+
+// Bool returns the field as a bool, or defaultValue if it doesn't exist
+func Bool(container ini.IniContainer, name string, defaultValue bool) (bool, error) {
+	return get(container, name, defaultValue)
+}
+
+// OptionalInt returns the field as an int, or nil if it doesn't exist
+func OptionalInt(container ini.IniContainer, name string) (*int, error) {
+	return getOptional[int](container, name)
+}
+
+// OptionalFloat returns the field as a float64, or nil if it doesn't exist
+func OptionalFloat(container ini.IniContainer, name string) (*float64, error) {
+	return getOptional[float64](container, name)
+}
+
+// OptionalBool returns the field as a bool, or nil if it doesn't exist
+func OptionalBool(container ini.IniContainer, name string) (*bool, error) {
+	return getOptional[bool](container, name)
+}
+
+// Container returns the field as a container, an empty container is returned if it doesn't exist
+func Container(container ini.IniContainer, name string) (ini.IniContainer, error) {
+	return get(container, name, ini.IniContainer{})
+}
+
+// List returns the elements of a list field like `("A","B")`, a single value that is not a list is returned as a list with one element
+func List(container ini.IniContainer, name string) []ini.ContainerKey {
+	field, exists := container.FindKey(name)
+	if !exists {
+		return nil
+	}
+	if list, err := field.AsContainer(); err == nil {
+		return list.KeyValues
+	}
+	return []ini.ContainerKey{*field}
+}
+
+// Elements returns the containers of a list of containers like `((A=1),(A=2))`, a single container written without the outer parentheses like `(A=1)` is returned as a list with one element
+func Elements(container ini.IniContainer, name string) ([]ini.IniContainer, error) {
+	list, err := Container(container, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ini.IniContainer
+	for i, element := range list.KeyValues {
+		if element.Key != "" {
+			return []ini.IniContainer{list}, nil
+		}
+		elementContainer, err := element.AsContainer()
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		result = append(result, elementContainer)
+	}
+	return result, nil
+}
+
+// Strings returns the elements of a list field as strings without the surrounding quotes
+func Strings(container ini.IniContainer, name string) []string {
+	var result []string
+	for _, element := range List(container, name) {
+		result = append(result, Unquote(element.ToValueString()))
+	}
+	return result
+}
+
+// Floats returns the elements of a list field as float64
+func Floats(container ini.IniContainer, name string) ([]float64, error) {
+	var result []float64
+	for i, element := range List(container, name) {
+		value, err := ini.Convert[float64](element.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func get[T ini.Value](container ini.IniContainer, name string, defaultValue T) (T, error) {
+	field, exists := container.FindKey(name)
+	if !exists {
+		return defaultValue, nil
+	}
+	value, err := ini.Convert[T](field.Value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s: %w", name, err)
+	}
+	return value, nil
+}
+
+func getOptional[T ini.Value](container ini.IniContainer, name string) (*T, error) {
+	if !Has(container, name) {
+		return nil, nil
+	}
+	var zero T
+	value, err := get(container, name, zero)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+//endregion
+
+//region Writing
+
+// Builder builds a container field by field
+type Builder struct {
+	keyValues []ini.ContainerKey
+}
+
+// Add adds a field with the given value
+func (b *Builder) Add(name string, value interface{}) *Builder {
+	b.keyValues = append(b.keyValues, ini.ContainerKey{Key: name, Value: value})
+	return b
+}
+
+// AddString adds a field with the value in double quotes
+func (b *Builder) AddString(name string, value string) *Builder {
+	return b.Add(name, Quote(value))
+}
+
+// AddOptional adds a field with the value the pointer points to, nothing is added if the pointer is nil
+func AddOptional[T any](b *Builder, name string, value *T) *Builder {
+	if value != nil {
+		b.Add(name, *value)
+	}
+	return b
+}
+
+// AddUnlessDefault adds a field unless it has the default value of the game, a field in present is always added so a parsed container keeps its fields
+func AddUnlessDefault[T comparable](b *Builder, name string, value T, defaultValue T, present map[string]bool) *Builder {
+	if value != defaultValue || present[name] {
+		b.Add(name, value)
+	}
+	return b
+}
+
+// AddStrings adds a list field with the values in double quotes
+func (b *Builder) AddStrings(name string, values []string) *Builder {
+	var list []ini.ContainerKey
+	for _, value := range values {
+		list = append(list, ini.ContainerKey{Value: Quote(value)})
+	}
+	return b.Add(name, ini.IniContainer{KeyValues: list})
+}
+
+// AddFloats adds a list field with the values
+func (b *Builder) AddFloats(name string, values []float64) *Builder {
+	var list []ini.ContainerKey
+	for _, value := range values {
+		list = append(list, ini.ContainerKey{Value: value})
+	}
+	return b.Add(name, ini.IniContainer{KeyValues: list})
+}
+
+// AddList adds a list field with the containers as its elements
+func (b *Builder) AddList(name string, elements []ini.IniContainer) *Builder {
+	list := make([]ini.ContainerKey, 0, len(elements))
+	for _, element := range elements {
+		list = append(list, ini.ContainerKey{Value: element})
+	}
+	return b.Add(name, ini.IniContainer{KeyValues: list})
+}
+
+// Container returns the built container
+func (b *Builder) Container() ini.IniContainer {
+	return ini.IniContainer{KeyValues: b.keyValues}
+}
+
+// Overlay returns a copy of parsed with the fields of built written over it, so the fields a model doesn't know are kept where they are.
+// The known fields that built doesn't have are removed, new fields are inserted after the field built before them
+// and a field that still has its value is kept as it was written.
+func Overlay(parsed ini.IniContainer, built ini.IniContainer, known ...string) ini.IniContainer {
+	result := parsed.Clone()
+	for _, name := range known {
+		if !Has(built, name) {
+			_ = result.Remove(name)
+		}
+	}
+
+	position := 0
+	for _, field := range built.KeyValues {
+		index := -1
+		for i := range result.KeyValues {
+			if result.KeyValues[i].Key == field.Key {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			result.KeyValues = append(result.KeyValues[:position], append([]ini.ContainerKey{field}, result.KeyValues[position:]...)...)
+			index = position
+		} else if !sameValue(result.KeyValues[index].Value, field.Value) {
+			result.KeyValues[index].Value = field.Value
+		}
+		if index >= position {
+			position = index + 1
+		}
+	}
+	return result
+}
+
+// sameValue returns true if the values are equal, a list with one element is the same as the element written without the parentheses of the list
+func sameValue(parsed interface{}, built interface{}) bool {
+	if ini.NewIniKey("", parsed).Equal(ini.NewIniKey("", built)) {
+		return true
+	}
+	list, isList := built.(ini.IniContainer)
+	return isList && len(list.KeyValues) == 1 && list.KeyValues[0].Key == "" && sameValue(parsed, list.KeyValues[0].Value)
+}
+
+//endregion
+
+// Issue is a problem found by the Validate method of a model
+type Issue struct {
+	// Path points to the invalid value e.g. ItemSets[0].ItemEntries[1].MinQuantity
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// Quote returns the value in double quotes
+func Quote(value string) string {
+	return `"` + value + `"`
+}
+
+// Unquote removes the double quotes around the value if it has them
+func Unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
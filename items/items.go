@@ -0,0 +1,381 @@
+// Package items reads and edits the item and spawn overrides of a Game.ini file: ConfigOverrideItemCraftingCosts,
+// ConfigOverrideItemMaxQuantity, HarvestResourceItemAmountClassMultipliers and ConfigAddNPCSpawnEntriesContainer.
+package items
+
+import (
+	"fmt"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+const (
+	CraftingCostsKey      = "ConfigOverrideItemCraftingCosts"
+	MaxQuantitiesKey      = "ConfigOverrideItemMaxQuantity"
+	HarvestMultipliersKey = "HarvestResourceItemAmountClassMultipliers"
+	SpawnContainersKey    = "ConfigAddNPCSpawnEntriesContainer"
+)
+
+// Keys lists the item keys, the game allows one line per item, resource or spawn container for each of them so they are parsed as duplicate keys
+var Keys = []string{CraftingCostsKey, MaxQuantitiesKey, HarvestMultipliersKey, SpawnContainersKey}
+
+// CraftingCost is a ConfigOverrideItemCraftingCosts entry, it replaces all resources needed to craft the item
+type CraftingCost struct {
+	ItemClass string
+	Resources []Resource
+}
+
+// Resource is a resource needed to craft an item
+type Resource struct {
+	ResourceClass string
+	Amount        float64
+	// RequireExactType disallows resources that can normally be used instead e.g. Metal Ingot instead of Scrap Metal Ingot
+	RequireExactType bool
+}
+
+// MaxQuantity is a ConfigOverrideItemMaxQuantity entry, it sets the stack size of the item
+type MaxQuantity struct {
+	ItemClass   string
+	MaxQuantity int
+	// IgnoreMultiplier stops ItemStackSizeMultiplier from changing the stack size
+	IgnoreMultiplier bool
+}
+
+// HarvestMultiplier is a HarvestResourceItemAmountClassMultipliers entry
+type HarvestMultiplier struct {
+	ResourceClass string
+	Multiplier    float64
+}
+
+// SpawnContainer is a ConfigAddNPCSpawnEntriesContainer entry, it adds spawn entries to a spawn region of a map
+type SpawnContainer struct {
+	ContainerClass string
+	Entries        []SpawnEntry
+	Limits         []SpawnLimit
+
+	// container is the parsed value, ToContainer keeps the fields the model doesn't know
+	container ini.IniContainer
+}
+
+// SpawnEntry is a weighted group of NPCs spawned together
+type SpawnEntry struct {
+	Name   string
+	Weight float64
+	NPCs   []string
+	// NPCChances are the chances of the NPCs to spawn, all NPCs always spawn when it is empty
+	NPCChances []float64
+
+	container ini.IniContainer
+}
+
+// SpawnLimit limits the share of an NPC class in the spawn region
+type SpawnLimit struct {
+	NPCClass string
+	// MaxPercentage is the highest share of the desired number of NPCs that may be this class, between 0 and 1
+	MaxPercentage float64
+
+	container ini.IniContainer
+}
+
+// Overrides holds all item overrides of a file in the order they appear
+type Overrides struct {
+	CraftingCosts      []CraftingCost
+	MaxQuantities      []MaxQuantity
+	HarvestMultipliers []HarvestMultiplier
+	SpawnContainers    []SpawnContainer
+}
+
+//region Loading and saving
+
+// Load reads all item overrides from the file, the key names are matched ignoring case
+func Load(file *ini.IniFile) (*Overrides, error) {
+	overrides := &Overrides{}
+	section, exists := fields.FindSection(file, fields.GameModeSection)
+	if !exists {
+		return overrides, nil
+	}
+
+	for i, key := range section.Keys {
+		keyName := fields.KeyName(key.Key, Keys...)
+		if keyName == "" {
+			continue
+		}
+
+		container, err := key.AsContainer()
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+		}
+		if err := overrides.read(keyName, container); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", key.Key, i, err)
+		}
+	}
+	return overrides, nil
+}
+
+// Save replaces all item overrides in the file with the overrides, the keys are added to the allowed duplicate keys of the file
+func (o *Overrides) Save(file *ini.IniFile) {
+	fields.AllowDuplicates(file, Keys...)
+	section := fields.GetOrCreateSection(file, fields.GameModeSection)
+	fields.ReplaceKeysIgnoringCase(section, CraftingCostsKey, toContainers(o.CraftingCosts, CraftingCost.ToContainer))
+	fields.ReplaceKeysIgnoringCase(section, MaxQuantitiesKey, toContainers(o.MaxQuantities, MaxQuantity.ToContainer))
+	fields.ReplaceKeysIgnoringCase(section, HarvestMultipliersKey, toContainers(o.HarvestMultipliers, HarvestMultiplier.ToContainer))
+	fields.ReplaceKeysIgnoringCase(section, SpawnContainersKey, toContainers(o.SpawnContainers, SpawnContainer.ToContainer))
+}
+
+func (o *Overrides) read(keyName string, container ini.IniContainer) error {
+	switch keyName {
+	case CraftingCostsKey:
+		cost, err := CraftingCostFromContainer(container)
+		if err != nil {
+			return err
+		}
+		o.CraftingCosts = append(o.CraftingCosts, cost)
+	case MaxQuantitiesKey:
+		maxQuantity, err := MaxQuantityFromContainer(container)
+		if err != nil {
+			return err
+		}
+		o.MaxQuantities = append(o.MaxQuantities, maxQuantity)
+	case HarvestMultipliersKey:
+		multiplier, err := HarvestMultiplierFromContainer(container)
+		if err != nil {
+			return err
+		}
+		o.HarvestMultipliers = append(o.HarvestMultipliers, multiplier)
+	case SpawnContainersKey:
+		spawnContainer, err := SpawnContainerFromContainer(container)
+		if err != nil {
+			return err
+		}
+		o.SpawnContainers = append(o.SpawnContainers, spawnContainer)
+	}
+	return nil
+}
+
+func toContainers[T any](entries []T, toContainer func(entry T) ini.IniContainer) []ini.IniContainer {
+	containers := make([]ini.IniContainer, 0, len(entries))
+	for _, entry := range entries {
+		containers = append(containers, toContainer(entry))
+	}
+	return containers
+}
+
+//endregion
+
+//region Conversion
+
+// CraftingCostFromContainer parses a ConfigOverrideItemCraftingCosts value
+func CraftingCostFromContainer(container ini.IniContainer) (CraftingCost, error) {
+	cost := CraftingCost{ItemClass: fields.String(container, "ItemClassString")}
+	elements, err := fields.Elements(container, "BaseCraftingResourceRequirements")
+	if err != nil {
+		return cost, err
+	}
+	for i, element := range elements {
+		resource := Resource{ResourceClass: fields.String(element, "ResourceItemTypeString")}
+		if resource.Amount, err = fields.Float(element, "BaseResourceRequirement", 0); err != nil {
+			return cost, fmt.Errorf("BaseCraftingResourceRequirements[%d]: %w", i, err)
+		}
+		if resource.RequireExactType, err = fields.Bool(element, "bCraftingRequireExactResourceType", false); err != nil {
+			return cost, fmt.Errorf("BaseCraftingResourceRequirements[%d]: %w", i, err)
+		}
+		cost.Resources = append(cost.Resources, resource)
+	}
+	return cost, nil
+}
+
+// ToContainer returns the crafting cost in the container syntax of the game
+func (c CraftingCost) ToContainer() ini.IniContainer {
+	resources := make([]ini.IniContainer, 0, len(c.Resources))
+	for _, resource := range c.Resources {
+		builder := &fields.Builder{}
+		resources = append(resources, builder.AddString("ResourceItemTypeString", resource.ResourceClass).
+			Add("BaseResourceRequirement", resource.Amount).
+			Add("bCraftingRequireExactResourceType", resource.RequireExactType).
+			Container())
+	}
+
+	builder := &fields.Builder{}
+	return builder.AddString("ItemClassString", c.ItemClass).AddList("BaseCraftingResourceRequirements", resources).Container()
+}
+
+// MaxQuantityFromContainer parses a ConfigOverrideItemMaxQuantity value
+func MaxQuantityFromContainer(container ini.IniContainer) (MaxQuantity, error) {
+	maxQuantity := MaxQuantity{ItemClass: fields.String(container, "ItemClassString")}
+	quantity, err := fields.Container(container, "Quantity")
+	if err != nil {
+		return maxQuantity, err
+	}
+	if maxQuantity.MaxQuantity, err = fields.Int(quantity, "MaxItemQuantity", 0); err != nil {
+		return maxQuantity, fmt.Errorf("Quantity: %w", err)
+	}
+	if maxQuantity.IgnoreMultiplier, err = fields.Bool(quantity, "bIgnoreMultiplier", false); err != nil {
+		return maxQuantity, fmt.Errorf("Quantity: %w", err)
+	}
+	return maxQuantity, nil
+}
+
+// ToContainer returns the max quantity in the container syntax of the game
+func (m MaxQuantity) ToContainer() ini.IniContainer {
+	quantity := &fields.Builder{}
+	quantity.Add("MaxItemQuantity", m.MaxQuantity).Add("bIgnoreMultiplier", m.IgnoreMultiplier)
+
+	builder := &fields.Builder{}
+	return builder.AddString("ItemClassString", m.ItemClass).Add("Quantity", quantity.Container()).Container()
+}
+
+// HarvestMultiplierFromContainer parses a HarvestResourceItemAmountClassMultipliers value
+func HarvestMultiplierFromContainer(container ini.IniContainer) (HarvestMultiplier, error) {
+	multiplier := HarvestMultiplier{ResourceClass: fields.String(container, "ClassName")}
+	var err error
+	multiplier.Multiplier, err = fields.Float(container, "Multiplier", 1)
+	return multiplier, err
+}
+
+// ToContainer returns the harvest multiplier in the container syntax of the game
+func (h HarvestMultiplier) ToContainer() ini.IniContainer {
+	builder := &fields.Builder{}
+	return builder.AddString("ClassName", h.ResourceClass).Add("Multiplier", h.Multiplier).Container()
+}
+
+// SpawnContainerFromContainer parses a ConfigAddNPCSpawnEntriesContainer value
+func SpawnContainerFromContainer(container ini.IniContainer) (SpawnContainer, error) {
+	spawnContainer := SpawnContainer{ContainerClass: fields.String(container, "NPCSpawnEntriesContainerClassString"), container: container}
+
+	entries, err := fields.Elements(container, "NPCSpawnEntries")
+	if err != nil {
+		return spawnContainer, err
+	}
+	for i, element := range entries {
+		entry := SpawnEntry{Name: fields.String(element, "AnEntryName"), NPCs: fields.Strings(element, "NPCsToSpawnStrings"), container: element}
+		if entry.Weight, err = fields.Float(element, "EntryWeight", 1); err != nil {
+			return spawnContainer, fmt.Errorf("NPCSpawnEntries[%d]: %w", i, err)
+		}
+		if entry.NPCChances, err = fields.Floats(element, "NPCsToSpawnPercentageChance"); err != nil {
+			return spawnContainer, fmt.Errorf("NPCSpawnEntries[%d]: %w", i, err)
+		}
+		spawnContainer.Entries = append(spawnContainer.Entries, entry)
+	}
+
+	limits, err := fields.Elements(container, "NPCSpawnLimits")
+	if err != nil {
+		return spawnContainer, err
+	}
+	for i, element := range limits {
+		limit := SpawnLimit{NPCClass: fields.String(element, "NPCClassString"), container: element}
+		if limit.MaxPercentage, err = fields.Float(element, "MaxPercentageOfDesiredNumToAllow", 1); err != nil {
+			return spawnContainer, fmt.Errorf("NPCSpawnLimits[%d]: %w", i, err)
+		}
+		spawnContainer.Limits = append(spawnContainer.Limits, limit)
+	}
+	return spawnContainer, nil
+}
+
+// ToContainer returns the spawn container in the container syntax of the game.
+// The fields of a loaded container the model doesn't know are kept, weights and percentages of 1 are only written if they were loaded.
+func (s SpawnContainer) ToContainer() ini.IniContainer {
+	entries := make([]ini.IniContainer, 0, len(s.Entries))
+	for _, entry := range s.Entries {
+		builder := &fields.Builder{}
+		builder.AddString("AnEntryName", entry.Name)
+		fields.AddUnlessDefault(builder, "EntryWeight", entry.Weight, 1, fields.Present(entry.container))
+		builder.AddStrings("NPCsToSpawnStrings", entry.NPCs)
+		if len(entry.NPCChances) > 0 {
+			builder.AddFloats("NPCsToSpawnPercentageChance", entry.NPCChances)
+		}
+		entries = append(entries, fields.Overlay(entry.container, builder.Container(), "AnEntryName", "EntryWeight", "NPCsToSpawnStrings", "NPCsToSpawnPercentageChance"))
+	}
+
+	builder := &fields.Builder{}
+	builder.AddString("NPCSpawnEntriesContainerClassString", s.ContainerClass).AddList("NPCSpawnEntries", entries)
+	if len(s.Limits) > 0 {
+		limits := make([]ini.IniContainer, 0, len(s.Limits))
+		for _, limit := range s.Limits {
+			limitBuilder := &fields.Builder{}
+			limitBuilder.AddString("NPCClassString", limit.NPCClass)
+			fields.AddUnlessDefault(limitBuilder, "MaxPercentageOfDesiredNumToAllow", limit.MaxPercentage, 1, fields.Present(limit.container))
+			limits = append(limits, fields.Overlay(limit.container, limitBuilder.Container(), "NPCClassString", "MaxPercentageOfDesiredNumToAllow"))
+		}
+		builder.AddList("NPCSpawnLimits", limits)
+	}
+	return fields.Overlay(s.container, builder.Container(), "NPCSpawnEntriesContainerClassString", "NPCSpawnEntries", "NPCSpawnLimits")
+}
+
+//endregion
+
+//region Editing
+
+// SetCraftingCost replaces the crafting cost of the same item class, or adds it if the item has none
+func (o *Overrides) SetCraftingCost(cost CraftingCost) {
+	o.CraftingCosts = upsert(o.CraftingCosts, cost, func(entry CraftingCost) string { return entry.ItemClass })
+}
+
+// RemoveCraftingCost removes the crafting cost of the item class and returns true if it existed
+func (o *Overrides) RemoveCraftingCost(itemClass string) bool {
+	var removed bool
+	o.CraftingCosts, removed = remove(o.CraftingCosts, itemClass, func(entry CraftingCost) string { return entry.ItemClass })
+	return removed
+}
+
+// SetMaxQuantity replaces the max quantity of the same item class, or adds it if the item has none
+func (o *Overrides) SetMaxQuantity(maxQuantity MaxQuantity) {
+	o.MaxQuantities = upsert(o.MaxQuantities, maxQuantity, func(entry MaxQuantity) string { return entry.ItemClass })
+}
+
+// RemoveMaxQuantity removes the max quantity of the item class and returns true if it existed
+func (o *Overrides) RemoveMaxQuantity(itemClass string) bool {
+	var removed bool
+	o.MaxQuantities, removed = remove(o.MaxQuantities, itemClass, func(entry MaxQuantity) string { return entry.ItemClass })
+	return removed
+}
+
+// SetHarvestMultiplier replaces the multiplier of the same resource class, or adds it if the resource has none
+func (o *Overrides) SetHarvestMultiplier(multiplier HarvestMultiplier) {
+	o.HarvestMultipliers = upsert(o.HarvestMultipliers, multiplier, func(entry HarvestMultiplier) string { return entry.ResourceClass })
+}
+
+// RemoveHarvestMultiplier removes the multiplier of the resource class and returns true if it existed
+func (o *Overrides) RemoveHarvestMultiplier(resourceClass string) bool {
+	var removed bool
+	o.HarvestMultipliers, removed = remove(o.HarvestMultipliers, resourceClass, func(entry HarvestMultiplier) string { return entry.ResourceClass })
+	return removed
+}
+
+// SetSpawnContainer replaces the entry of the same spawn container class, or adds it if the container has none
+func (o *Overrides) SetSpawnContainer(spawnContainer SpawnContainer) {
+	o.SpawnContainers = upsert(o.SpawnContainers, spawnContainer, func(entry SpawnContainer) string { return entry.ContainerClass })
+}
+
+// RemoveSpawnContainer removes the entry of the spawn container class and returns true if it existed
+func (o *Overrides) RemoveSpawnContainer(containerClass string) bool {
+	var removed bool
+	o.SpawnContainers, removed = remove(o.SpawnContainers, containerClass, func(entry SpawnContainer) string { return entry.ContainerClass })
+	return removed
+}
+
+// upsert replaces the first entry with the same class ignoring case and removes any other, or appends the entry if there is none
+func upsert[T any](entries []T, entry T, classOf func(entry T) string) []T {
+	class := classOf(entry)
+	for i := range entries {
+		if strings.EqualFold(classOf(entries[i]), class) {
+			entries[i] = entry
+			rest, _ := remove(entries[i+1:], class, classOf)
+			return append(entries[:i+1], rest...)
+		}
+	}
+	return append(entries, entry)
+}
+
+// remove removes all entries with the class ignoring case and returns true if there were any
+func remove[T any](entries []T, class string, classOf func(entry T) string) ([]T, bool) {
+	kept := entries[:0]
+	for _, entry := range entries {
+		if !strings.EqualFold(classOf(entry), class) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, len(kept) != len(entries)
+}
+
+//endregion
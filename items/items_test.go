@@ -0,0 +1,148 @@
+package items
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestLoad(t *testing.T) {
+	// A list with a single element may be written without the outer parentheses
+	file, _ := ini.DeserializeIniFile(`[/script/shootergame.shootergamemode]
+ConfigOverrideItemCraftingCosts=(ItemClassString="PrimalItemConsumable_Kibble_Base_XL_C",BaseCraftingResourceRequirements=(ResourceItemTypeString="PrimalItemConsumable_Egg_Rex_C",BaseResourceRequirement=1.0,bCraftingRequireExactResourceType=true))
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=1000))
+HarvestResourceItemAmountClassMultipliers=(ClassName="PrimalItemResource_Thatch_C")
+ConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntriesContainerClassString="DinoSpawnEntriesBeach_C",NPCSpawnEntries=((AnEntryName="DodoPack",NPCsToSpawnStrings=("Dodo_Character_BP_C","Dodo_Character_BP_C"),NPCsToSpawnPercentageChance=(1.0,0.5))),NPCSpawnLimits=((NPCClassString="Dodo_Character_BP_C")))
+`, Keys...)
+	overrides, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCost := CraftingCost{ItemClass: "PrimalItemConsumable_Kibble_Base_XL_C", Resources: []Resource{{ResourceClass: "PrimalItemConsumable_Egg_Rex_C", Amount: 1, RequireExactType: true}}}
+	if len(overrides.CraftingCosts) != 1 || !reflect.DeepEqual(overrides.CraftingCosts[0], expectedCost) {
+		t.Errorf("unexpected crafting costs %+v", overrides.CraftingCosts)
+	}
+	if expected := []MaxQuantity{{ItemClass: "PrimalItemResource_Stone_C", MaxQuantity: 1000}}; !reflect.DeepEqual(overrides.MaxQuantities, expected) {
+		t.Errorf("unexpected max quantities %+v", overrides.MaxQuantities)
+	}
+	if overrides.HarvestMultipliers[0].Multiplier != 1 {
+		t.Errorf("a missing multiplier should default to 1 %+v", overrides.HarvestMultipliers)
+	}
+	expectedSpawn := SpawnContainer{
+		ContainerClass: "DinoSpawnEntriesBeach_C",
+		Entries:        []SpawnEntry{{Name: "DodoPack", Weight: 1, NPCs: []string{"Dodo_Character_BP_C", "Dodo_Character_BP_C"}, NPCChances: []float64{1, 0.5}}},
+		Limits:         []SpawnLimit{{NPCClass: "Dodo_Character_BP_C", MaxPercentage: 1}},
+	}
+	if len(overrides.SpawnContainers) != 1 || !reflect.DeepEqual(withoutParsed(overrides.SpawnContainers[0]), expectedSpawn) {
+		t.Errorf("unexpected spawn containers %+v", overrides.SpawnContainers)
+	}
+
+	broken, _ := ini.DeserializeIniFile("[/Script/ShooterGame.ShooterGameMode]\nConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntries=((EntryWeight=high)))\n", Keys...)
+	if _, err := Load(broken); err == nil || !strings.Contains(err.Error(), "NPCSpawnEntries[0]: EntryWeight") {
+		t.Errorf("expected an error pointing to the entry, got %v", err)
+	}
+}
+
+func TestSave(t *testing.T) {
+	data := `[/Script/ShooterGame.ShooterGameMode]
+HarvestResourceItemAmountClassMultipliers=(ClassName="PrimalItemResource_Thatch_C",Multiplier=0.50)
+bAllowUnlimitedRespecs=True
+harvestresourceitemamountclassmultipliers=(ClassName="PrimalItemResource_Wood_C",Multiplier=2.0)
+`
+	// Key names are matched ignoring case like the game does
+	file, _ := ini.DeserializeIniFile(data, Keys...)
+	overrides, _ := Load(file)
+	overrides.Save(file)
+	if output := file.ToString(); output != data {
+		t.Errorf("saving unchanged overrides changed the file:\n%s", output)
+	}
+
+	overrides.RemoveHarvestMultiplier("PrimalItemResource_Thatch_C")
+	overrides.SetHarvestMultiplier(HarvestMultiplier{ResourceClass: "PrimalItemResource_Stone_C", Multiplier: 3})
+	overrides.SetMaxQuantity(MaxQuantity{ItemClass: "PrimalItemResource_Stone_C", MaxQuantity: 200, IgnoreMultiplier: true})
+	overrides.SetSpawnContainer(SpawnContainer{ContainerClass: "DinoSpawnEntriesBeach_C", Entries: []SpawnEntry{{Name: "Rex", Weight: 0.1, NPCs: []string{"Rex_Character_BP_C"}}}})
+	overrides.Save(file)
+
+	// The entries keep the lines of the key in order, so the wood multiplier moves up to the line of the removed thatch multiplier
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+HarvestResourceItemAmountClassMultipliers=(ClassName="PrimalItemResource_Wood_C",Multiplier=2)
+bAllowUnlimitedRespecs=True
+HarvestResourceItemAmountClassMultipliers=(ClassName="PrimalItemResource_Stone_C",Multiplier=3)
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=200,bIgnoreMultiplier=true))
+ConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntriesContainerClassString="DinoSpawnEntriesBeach_C",NPCSpawnEntries=((AnEntryName="Rex",EntryWeight=0.1,NPCsToSpawnStrings=("Rex_Character_BP_C"))))
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected file:\n%s", output)
+	}
+}
+
+func TestSaveKeepsUnknownFields(t *testing.T) {
+	data := `[/Script/ShooterGame.ShooterGameMode]
+ConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntriesContainerClassString="DinoSpawnEntriesBeach_C",NPCSpawnEntries=((AnEntryName="DodoPack",NPCsToSpawnStrings=("Dodo_Character_BP_C","Dodo_Character_BP_C"),NPCsSpawnOffsets=((X=0,Y=0,Z=0),(X=100,Y=0,Z=0)),GroupSpawnRadius=400)),NPCSpawnLimits=(NPCClassString="Dodo_Character_BP_C",MaxPercentageOfDesiredNumToAllow=0.5),bOverrideLimits=true)
+`
+	file, _ := ini.DeserializeIniFile(data, Keys...)
+	overrides, _ := Load(file)
+	overrides.Save(file)
+	if output := file.ToString(); output != data {
+		t.Errorf("saving an unchanged spawn container changed the file:\n%s", output)
+	}
+
+	overrides.SpawnContainers[0].Entries[0].Weight = 0.5
+	overrides.SpawnContainers[0].Limits = nil
+	overrides.Save(file)
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+ConfigAddNPCSpawnEntriesContainer=(NPCSpawnEntriesContainerClassString="DinoSpawnEntriesBeach_C",NPCSpawnEntries=((AnEntryName="DodoPack",EntryWeight=0.5,NPCsToSpawnStrings=("Dodo_Character_BP_C","Dodo_Character_BP_C"),NPCsSpawnOffsets=((X=0,Y=0,Z=0),(X=100,Y=0,Z=0)),GroupSpawnRadius=400)),bOverrideLimits=true)
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected file:\n%s", output)
+	}
+}
+
+func TestEditing(t *testing.T) {
+	overrides := &Overrides{
+		HarvestMultipliers: []HarvestMultiplier{
+			{ResourceClass: "PrimalItemResource_Stone_C", Multiplier: 2},
+			{ResourceClass: "PrimalItemResource_Wood_C", Multiplier: 2},
+			{ResourceClass: "primalitemresource_stone_c", Multiplier: 3},
+		},
+	}
+
+	overrides.SetHarvestMultiplier(HarvestMultiplier{ResourceClass: "PrimalItemResource_Stone_C", Multiplier: 4})
+	expected := []HarvestMultiplier{
+		{ResourceClass: "PrimalItemResource_Stone_C", Multiplier: 4},
+		{ResourceClass: "PrimalItemResource_Wood_C", Multiplier: 2},
+	}
+	if !reflect.DeepEqual(overrides.HarvestMultipliers, expected) {
+		t.Errorf("expected the duplicate to be removed, got %+v", overrides.HarvestMultipliers)
+	}
+
+	overrides.SetMaxQuantity(MaxQuantity{ItemClass: "PrimalItemResource_Stone_C", MaxQuantity: 200})
+	overrides.SetMaxQuantity(MaxQuantity{ItemClass: "PrimalItemResource_Stone_C", MaxQuantity: 500})
+	if len(overrides.MaxQuantities) != 1 || overrides.MaxQuantities[0].MaxQuantity != 500 {
+		t.Errorf("unexpected max quantities %+v", overrides.MaxQuantities)
+	}
+
+	if !overrides.RemoveHarvestMultiplier("PrimalItemResource_Wood_C") || overrides.RemoveHarvestMultiplier("PrimalItemResource_Wood_C") {
+		t.Error("expected the multiplier to be removed once")
+	}
+	if overrides.RemoveCraftingCost("PrimalItem_WeaponGun_C") || overrides.RemoveSpawnContainer("DinoSpawnEntriesBeach_C") {
+		t.Error("expected nothing to be removed")
+	}
+}
+
+// withoutParsed returns the spawn container without the parsed containers, so it can be compared to one built in code
+func withoutParsed(spawnContainer SpawnContainer) SpawnContainer {
+	spawnContainer.container = ini.IniContainer{}
+	spawnContainer.Entries = append([]SpawnEntry(nil), spawnContainer.Entries...)
+	for i := range spawnContainer.Entries {
+		spawnContainer.Entries[i].container = ini.IniContainer{}
+	}
+	spawnContainer.Limits = append([]SpawnLimit(nil), spawnContainer.Limits...)
+	for i := range spawnContainer.Limits {
+		spawnContainer.Limits[i].container = ini.IniContainer{}
+	}
+	return spawnContainer
+}
@@ -1,7 +1,6 @@
 package ini
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 )
@@ -21,6 +20,8 @@ const (
 type IniKey struct {
 	Key   string
 	Value interface{}
+	// Raw is the text the value was parsed from, it is empty for keys created in code and cleared when the value is replaced
+	Raw string
 }
 
 // ToString returns the key as a string in ini format
@@ -28,21 +29,21 @@ func (k *IniKey) ToString() string {
 	return fmt.Sprintf("%s=%s", k.Key, k.ToValueString())
 }
 
-// ToValueString returns the key's value as a string
+// ToValueString returns the key's value as a string, the value is written as it was parsed (e.g. 0012, 1.50 or True) as long as it is unchanged
 func (k *IniKey) ToValueString() string {
-	if container, ok := k.Value.(IniContainer); ok {
-		return container.ToString()
-	} else {
-		return fmt.Sprintf("%v", k.Value)
+	formatted := formatValue(k.Value)
+	if k.Raw != "" && k.Raw != formatted && formatValue(toGuessedType(strings.TrimSpace(k.Raw))) == formatted {
+		return k.Raw
 	}
+	return formatted
 }
 
 // ToContainerString returns the key value as a container string e.g. "OverrideNamedEngramEntries=(EngramClassName="EngramEntry_CryoGun_Mod_C",EngramHidden=True,EngramPointsCost=0,EngramLevelRequirement=90,RemoveEngramPreReq=False)"
 func (k *IniKey) ToContainerString() (string, error) {
-	if container, ok := k.Value.(IniContainer); ok {
-		return container.ToString(), nil
+	if typeOfValue(k.Value) == Container {
+		return formatValue(k.Value), nil
 	} else {
-		return "", errors.New("key value is not a container")
+		return "", newTypeError(k.Key, Container, k.Value)
 	}
 }
 
@@ -63,6 +64,7 @@ func NewParsedIniKey(keyString string) *IniKey {
 
 	if len(splitKeyString) > 1 {
 		key.Value = toGuessedType(splitKeyString[1])
+		key.Raw = splitKeyString[1]
 	}
 
 	return key
@@ -80,7 +82,7 @@ func (k *IniKey) AsString() (string, error) {
 	if s, ok := k.Value.(string); ok {
 		return s, nil
 	} else {
-		return "", errors.New("key value is not a string")
+		return "", newTypeError(k.Key, String, k.Value)
 	}
 }
 
@@ -89,7 +91,7 @@ func (k *IniKey) AsInt() (int, error) {
 	if i, ok := k.Value.(int); ok {
 		return i, nil
 	} else {
-		return -1, errors.New("key value is not an int")
+		return -1, newTypeError(k.Key, Int, k.Value)
 	}
 }
 
@@ -98,7 +100,7 @@ func (k *IniKey) AsFloat64() (float64, error) {
 	if f, ok := k.Value.(float64); ok {
 		return f, nil
 	} else {
-		return -1, errors.New("key value is not a float64")
+		return -1, newTypeError(k.Key, Float64, k.Value)
 	}
 }
 
@@ -107,17 +109,35 @@ func (k *IniKey) AsBool() (bool, error) {
 	if b, ok := k.Value.(bool); ok {
 		return b, nil
 	} else {
-		return false, errors.New("key value is not a bool")
+		return false, newTypeError(k.Key, Boolean, k.Value)
 	}
 }
 
-// AsContainer returns the key value as a container
+// AsContainer returns the key value as a container, a []ContainerKey value is returned as an IniContainer holding it
 func (k *IniKey) AsContainer() (IniContainer, error) {
 	if container, ok := k.Value.(IniContainer); ok {
 		return container, nil
+	} else if keyValues, ok := k.Value.([]ContainerKey); ok {
+		return IniContainer{KeyValues: keyValues}, nil
 	} else {
-		return IniContainer{}, errors.New("key value is not a container")
+		return IniContainer{}, newTypeError(k.Key, Container, k.Value)
+	}
+}
+
+// EditContainer calls fn with a copy of the container value of the key, if fn returns nil the edited copy replaces the value of the key.
+// Use IniSection.EditContainerKey to notify observers.
+func (k *IniKey) EditContainer(fn func(container *IniContainer) error) error {
+	container, err := k.AsContainer()
+	if err != nil {
+		return err
+	}
+	container = container.Clone()
+	if err := fn(&container); err != nil {
+		return err
 	}
+	k.Value = container
+	k.Raw = ""
+	return nil
 }
 
 // AsGuessedValue returns the key value as a guessed value and the value type
@@ -133,6 +153,8 @@ func (k *IniKey) AsGuessedValue() (interface{}, KeyType) {
 		return k.Value.(bool), Boolean
 	case IniContainer:
 		return k.Value.(IniContainer), Container
+	case []ContainerKey:
+		return IniContainer{KeyValues: k.Value.([]ContainerKey)}, Container
 	default:
 		return nil, Fail
 	}
@@ -0,0 +1,271 @@
+// Package levels generates, reads and validates the level curves of a Game.ini file: LevelExperienceRampOverrides,
+// OverrideMaxExperiencePointsPlayer, OverrideMaxExperiencePointsDino and OverridePlayerLevelEngramPoints.
+package levels
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+const (
+	// RampKey holds the player ramp on its first line and the dino ramp on its second line
+	RampKey                = "LevelExperienceRampOverrides"
+	MaxExperiencePlayerKey = "OverrideMaxExperiencePointsPlayer"
+	MaxExperienceDinoKey   = "OverrideMaxExperiencePointsDino"
+	// EngramPointsKey holds the engram points given for each player level, one line per level starting at level 1
+	EngramPointsKey = "OverridePlayerLevelEngramPoints"
+)
+
+// Keys lists the keys that hold one line per ramp or level, without them as allowed duplicate keys only the last line is parsed
+var Keys = []string{RampKey, EngramPointsKey}
+
+// ErrMissingPlayerRamp is returned by Save when there is a dino ramp without a player ramp, the game would read the dino ramp as the player ramp
+var ErrMissingPlayerRamp = errors.New("a dino ramp needs a player ramp")
+
+const rampFieldPrefix = "ExperiencePointsForLevel["
+
+//region Ramps
+
+// Ramp is the total experience needed to reach each level, Ramp[0] is the experience needed for level 2 as level 1 is reached at 0
+type Ramp []int
+
+// Table returns a ramp with the given experience values, see Ramp
+func Table(experience ...int) Ramp {
+	return append(Ramp(nil), experience...)
+}
+
+// Linear returns a ramp up to maxLevel where level 2 needs first experience and every next level needs step more than the one before
+func Linear(maxLevel int, first int, step int) Ramp {
+	return Formula(maxLevel, func(level int) float64 {
+		return float64(first + (level-2)*step)
+	})
+}
+
+// Exponential returns a ramp up to maxLevel where level 2 needs first experience and every next level needs growth times the one before
+func Exponential(maxLevel int, first float64, growth float64) Ramp {
+	return Formula(maxLevel, func(level int) float64 {
+		return first * math.Pow(growth, float64(level-2))
+	})
+}
+
+// Formula returns a ramp up to maxLevel with the result of experience for every level from 2 to maxLevel, the results are rounded
+func Formula(maxLevel int, experience func(level int) float64) Ramp {
+	var ramp Ramp
+	for level := 2; level <= maxLevel; level++ {
+		ramp = append(ramp, int(math.Round(experience(level))))
+	}
+	return ramp
+}
+
+// ParseRamp reads a ramp from a container like (ExperiencePointsForLevel[0]=5,ExperiencePointsForLevel[1]=20), the fields may be in any order.
+// Experience is a whole number in the game, so a value with a fractional part is an error.
+func ParseRamp(container ini.IniContainer) (Ramp, error) {
+	values := make(map[int]int)
+	for _, field := range container.KeyValues {
+		if !strings.HasPrefix(field.Key, rampFieldPrefix) || !strings.HasSuffix(field.Key, "]") {
+			return nil, fmt.Errorf("unexpected field %q", field.Key)
+		}
+		index, err := strconv.Atoi(field.Key[len(rampFieldPrefix) : len(field.Key)-1])
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("%s: invalid level index", field.Key)
+		}
+		if _, exists := values[index]; exists {
+			return nil, fmt.Errorf("%s: set more than once", field.Key)
+		}
+		experience, err := ini.Convert[int](field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Key, err)
+		}
+		values[index] = experience
+	}
+
+	ramp := make(Ramp, len(values))
+	for i := range ramp {
+		experience, exists := values[i]
+		if !exists {
+			return nil, fmt.Errorf("%s%d] is missing", rampFieldPrefix, i)
+		}
+		ramp[i] = experience
+	}
+	return ramp, nil
+}
+
+// ToContainer returns the ramp in the container syntax of the game
+func (r Ramp) ToContainer() ini.IniContainer {
+	builder := &fields.Builder{}
+	for i, experience := range r {
+		builder.Add(rampFieldPrefix+strconv.Itoa(i)+"]", experience)
+	}
+	return builder.Container()
+}
+
+// MaxLevel returns the highest level of the ramp
+func (r Ramp) MaxLevel() int {
+	return len(r) + 1
+}
+
+// MaxExperience returns the experience needed for the highest level of the ramp
+func (r Ramp) MaxExperience() int {
+	if len(r) == 0 {
+		return 0
+	}
+	return r[len(r)-1]
+}
+
+//endregion
+
+//region Loading and saving
+
+// Config holds all level overrides of a file, ramps that are empty and values that are 0 or nil are not written
+type Config struct {
+	Player              Ramp
+	Dino                Ramp
+	MaxExperiencePlayer int
+	MaxExperienceDino   int
+	// EngramPoints are the engram points given for each player level, EngramPoints[0] is given for level 1
+	EngramPoints []int
+}
+
+// Issue is a problem found by Validate, its Path points into the config e.g. Player[4]
+type Issue = fields.Issue
+
+// Load reads all level overrides from the file, the key names are matched ignoring case
+func Load(file *ini.IniFile) (*Config, error) {
+	config := &Config{}
+	section, exists := fields.FindSection(file, fields.GameModeSection)
+	if !exists {
+		return config, nil
+	}
+
+	for i, key := range fields.FindKeys(section, RampKey) {
+		if i > 1 {
+			return nil, fmt.Errorf("%s: only a player and a dino line are allowed", RampKey)
+		}
+		container, err := key.AsContainer()
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", RampKey, i, err)
+		}
+		ramp, err := ParseRamp(container)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", RampKey, i, err)
+		}
+		if i == 0 {
+			config.Player = ramp
+		} else {
+			config.Dino = ramp
+		}
+	}
+
+	var err error
+	if config.MaxExperiencePlayer, err = getInt(section, MaxExperiencePlayerKey); err != nil {
+		return nil, err
+	}
+	if config.MaxExperienceDino, err = getInt(section, MaxExperienceDinoKey); err != nil {
+		return nil, err
+	}
+	for i, key := range fields.FindKeys(section, EngramPointsKey) {
+		points, err := ini.Convert[int](key.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", EngramPointsKey, i, err)
+		}
+		config.EngramPoints = append(config.EngramPoints, points)
+	}
+	return config, nil
+}
+
+// Save replaces all level overrides in the file with the config, the keys are added to the allowed duplicate keys of the file.
+// ErrMissingPlayerRamp is returned and the file is left unchanged if the config has a dino ramp but no player ramp.
+func (c *Config) Save(file *ini.IniFile) error {
+	if len(c.Player) == 0 && len(c.Dino) > 0 {
+		return ErrMissingPlayerRamp
+	}
+	fields.AllowDuplicates(file, Keys...)
+	section := fields.GetOrCreateSection(file, fields.GameModeSection)
+
+	var ramps []ini.IniContainer
+	if len(c.Player) > 0 {
+		ramps = append(ramps, c.Player.ToContainer())
+	}
+	if len(c.Dino) > 0 {
+		ramps = append(ramps, c.Dino.ToContainer())
+	}
+	fields.ReplaceKeysIgnoringCase(section, RampKey, ramps)
+
+	setInt(section, MaxExperiencePlayerKey, c.MaxExperiencePlayer)
+	setInt(section, MaxExperienceDinoKey, c.MaxExperienceDino)
+	fields.ReplaceKeysIgnoringCase(section, EngramPointsKey, c.EngramPoints)
+	return nil
+}
+
+// SyncMaxExperience sets the max experience of players and dinos to the experience needed for the highest level of their ramp
+func (c *Config) SyncMaxExperience() {
+	if len(c.Player) > 0 {
+		c.MaxExperiencePlayer = c.Player.MaxExperience()
+	}
+	if len(c.Dino) > 0 {
+		c.MaxExperienceDino = c.Dino.MaxExperience()
+	}
+}
+
+// Validate returns all problems of the config, the config is valid if nothing is returned
+func (c *Config) Validate() []Issue {
+	var issues []Issue
+	issues = append(issues, validateRamp("Player", c.Player, c.MaxExperiencePlayer)...)
+	issues = append(issues, validateRamp("Dino", c.Dino, c.MaxExperienceDino)...)
+	if len(c.Player) == 0 && len(c.Dino) > 0 {
+		issues = append(issues, Issue{Path: "Player", Message: "is empty, the game reads the first ramp as the player ramp"})
+	}
+
+	if len(c.EngramPoints) > 0 && len(c.Player) > 0 && len(c.EngramPoints) != c.Player.MaxLevel() {
+		issues = append(issues, Issue{Path: "EngramPoints", Message: fmt.Sprintf("has %d levels but the player ramp has %d", len(c.EngramPoints), c.Player.MaxLevel())})
+	}
+	for i, points := range c.EngramPoints {
+		if points < 0 {
+			issues = append(issues, Issue{Path: fmt.Sprintf("EngramPoints[%d]", i), Message: fmt.Sprintf("must not be negative, got %d", points)})
+		}
+	}
+	return issues
+}
+
+func validateRamp(name string, ramp Ramp, maxExperience int) []Issue {
+	var issues []Issue
+	for i, experience := range ramp {
+		if experience <= 0 {
+			issues = append(issues, Issue{Path: fmt.Sprintf("%s[%d]", name, i), Message: fmt.Sprintf("must be greater than 0, got %d", experience)})
+		} else if i > 0 && experience <= ramp[i-1] {
+			issues = append(issues, Issue{Path: fmt.Sprintf("%s[%d]", name, i), Message: fmt.Sprintf("must be greater than the previous level (%d <= %d)", experience, ramp[i-1])})
+		}
+	}
+	if maxExperience > 0 && maxExperience < ramp.MaxExperience() {
+		issues = append(issues, Issue{Path: "MaxExperience" + name, Message: fmt.Sprintf("is lower than the experience needed for level %d (%d < %d)", ramp.MaxLevel(), maxExperience, ramp.MaxExperience())})
+	}
+	return issues
+}
+
+func getInt(section *ini.IniSection, keyName string) (int, error) {
+	key, exists := fields.FindKey(section, keyName)
+	if !exists {
+		return 0, nil
+	}
+	value, err := ini.Convert[int](key.Value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", keyName, err)
+	}
+	return value, nil
+}
+
+func setInt(section *ini.IniSection, keyName string, value int) {
+	var values []int
+	if value != 0 {
+		values = append(values, value)
+	}
+	fields.ReplaceKeysIgnoringCase(section, keyName, values)
+}
+
+//endregion
@@ -0,0 +1,137 @@
+package levels
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestCurves(t *testing.T) {
+	if ramp := Linear(5, 10, 5); !reflect.DeepEqual(ramp, Ramp{10, 15, 20, 25}) {
+		t.Errorf("unexpected linear ramp %v", ramp)
+	}
+	if ramp := Exponential(4, 100, 1.5); !reflect.DeepEqual(ramp, Ramp{100, 150, 225}) {
+		t.Errorf("unexpected exponential ramp %v", ramp)
+	}
+	ramp := Formula(4, func(level int) float64 {
+		return float64(level * level)
+	})
+	if !reflect.DeepEqual(ramp, Ramp{4, 9, 16}) || ramp.MaxLevel() != 4 || ramp.MaxExperience() != 16 {
+		t.Errorf("unexpected formula ramp %v", ramp)
+	}
+
+	container := Table(5, 20).ToContainer()
+	if value := container.ToString(); value != "(ExperiencePointsForLevel[0]=5,ExperiencePointsForLevel[1]=20)" {
+		t.Errorf("unexpected container %s", value)
+	}
+	if parsed, err := ParseRamp(container); err != nil || !reflect.DeepEqual(parsed, Ramp{5, 20}) {
+		t.Errorf("unexpected parsed ramp %v %v", parsed, err)
+	}
+}
+
+func TestParseRamp(t *testing.T) {
+	// The game accepts the levels in any order and writes whole numbers as floats
+	container, _ := ini.NewParsedIniKey("Ramp=(ExperiencePointsForLevel[1]=50.0,ExperiencePointsForLevel[0]=20)").AsContainer()
+	if ramp, err := ParseRamp(container); err != nil || !reflect.DeepEqual(ramp, Ramp{20, 50}) {
+		t.Errorf("unexpected ramp %v %v", ramp, err)
+	}
+
+	for _, value := range []string{
+		"(ExperiencePointsForLevel[1]=5)",
+		"(ExperiencePointsForLevel[0]=5,ExperiencePointsForLevel[0]=6)",
+		"(ExperiencePointsForLevel[0]=5.5)",
+		"(ExperiencePointsForLevel[-1]=5)",
+		"(Level[0]=5)",
+	} {
+		container, _ := ini.NewParsedIniKey("Ramp=" + value).AsContainer()
+		if ramp, err := ParseRamp(container); err == nil {
+			t.Errorf("%s: expected an error, got %v", value, ramp)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file, _ := ini.DeserializeIniFile(`[/script/shootergame.shootergamemode]
+LevelExperienceRampOverrides=(ExperiencePointsForLevel[0]=10,ExperiencePointsForLevel[1]=30)
+OverridePlayerLevelEngramPoints=0
+OverridePlayerLevelEngramPoints=8
+OverrideMaxExperiencePointsPlayer=30
+`, Keys...)
+	config, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Config{Player: Ramp{10, 30}, MaxExperiencePlayer: 30, EngramPoints: []int{0, 8}}
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	threeRamps, _ := ini.DeserializeIniFile("[/Script/ShooterGame.ShooterGameMode]\n"+strings.Repeat("LevelExperienceRampOverrides=(ExperiencePointsForLevel[0]=10)\n", 3), Keys...)
+	if _, err := Load(threeRamps); err == nil {
+		t.Error("expected an error for a third ramp")
+	}
+}
+
+func TestSave(t *testing.T) {
+	// Key names are matched ignoring case like the game does, unchanged keys keep their case
+	data := `[/Script/ShooterGame.ShooterGameMode]
+overridemaxexperiencepointsplayer=70
+LevelExperienceRampOverrides=(ExperiencePointsForLevel[0]=10,ExperiencePointsForLevel[1]=30,ExperiencePointsForLevel[2]=70)
+OverridePlayerLevelEngramPoints=0
+overrideplayerlevelengrampoints=8
+bAllowUnlimitedRespecs=True
+`
+	file, _ := ini.DeserializeIniFile(data, Keys...)
+	config, _ := Load(file)
+
+	// Without a player ramp the game would use the dino ramp for players
+	config.Player, config.Dino = nil, Ramp{20, 50}
+	if err := config.Save(file); !errors.Is(err, ErrMissingPlayerRamp) || file.ToString() != data {
+		t.Errorf("expected ErrMissingPlayerRamp and an unchanged file, got %v\n%s", err, file.ToString())
+	}
+
+	config.Player = Linear(4, 10, 20)
+	config.SyncMaxExperience()
+	config.EngramPoints = append(config.EngramPoints, 10, 12)
+	if err := config.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+OverrideMaxExperiencePointsPlayer=50
+LevelExperienceRampOverrides=(ExperiencePointsForLevel[0]=10,ExperiencePointsForLevel[1]=30,ExperiencePointsForLevel[2]=50)
+LevelExperienceRampOverrides=(ExperiencePointsForLevel[0]=20,ExperiencePointsForLevel[1]=50)
+OverridePlayerLevelEngramPoints=0
+overrideplayerlevelengrampoints=8
+OverridePlayerLevelEngramPoints=10
+OverridePlayerLevelEngramPoints=12
+bAllowUnlimitedRespecs=True
+OverrideMaxExperiencePointsDino=50
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	config := &Config{
+		Player:              Ramp{10, 10, 5},
+		Dino:                Ramp{0, 5},
+		MaxExperiencePlayer: 3,
+		EngramPoints:        []int{1, -1},
+	}
+	var paths []string
+	for _, issue := range config.Validate() {
+		paths = append(paths, issue.Path)
+	}
+	expected := []string{"Player[1]", "Player[2]", "MaxExperiencePlayer", "Dino[0]", "EngramPoints", "EngramPoints[1]"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected issues %v", paths)
+	}
+
+	if _, err := ParseRamp(ini.IniContainer{KeyValues: []ini.ContainerKey{{Key: "ExperiencePointsForLevel[1]", Value: 5}}}); err == nil {
+		t.Error("expected an error for a missing level")
+	}
+}
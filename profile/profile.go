@@ -0,0 +1,287 @@
+// Package profile resolves named sets of overrides with an optional parent profile into an IniFile, e.g. "pvp-base" → "pvp-boosted-weekend".
+//
+// A profile is an ini fragment. The optional [Profile] section holds the name of the parent (Parent=pvp-base), every other section holds overrides:
+//
+//	Key=Value   sets the key, for keys that may appear more than once the first line of a profile replaces all values of the parent
+//	+Key=Value  adds the value unless the key already has it
+//	.Key=Value  adds the value even if the key already has it
+//	-Key=Value  removes the keys with the value
+//	!Key        removes all keys with the name
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+// MetaSection is the section of a fragment that describes the profile itself
+const MetaSection = "Profile"
+
+// Extension is the file extension of profile fragments
+const Extension = ".ini"
+
+var (
+	// ErrProfileNotFound is returned when a profile or its parent does not exist
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrCycle is returned when a profile is its own ancestor
+	ErrCycle = errors.New("profile inherits from itself")
+)
+
+// Operator is the Unreal array operator of an override
+type Operator string
+
+const (
+	Set       Operator = ""
+	Add       Operator = "+"
+	AddAlways Operator = "."
+	Remove    Operator = "-"
+	Clear     Operator = "!"
+)
+
+// Override is a single line of a profile
+type Override struct {
+	Section  string
+	Key      string
+	Operator Operator
+	// Value is not used by Clear
+	Value interface{}
+}
+
+// Profile is a named set of overrides
+type Profile struct {
+	Name string
+	// Parent is the name of the profile the overrides are applied on, it is empty for a profile without a parent
+	Parent    string
+	Overrides []Override
+}
+
+// Profiles holds profiles by name
+type Profiles map[string]*Profile
+
+// Origin tells which profile set a value of the resolved file
+type Origin struct {
+	Section string
+	Key     string
+	Value   interface{}
+	Profile string
+}
+
+// Resolved is a profile with all its ancestors applied
+type Resolved struct {
+	File *ini.IniFile
+	// Chain holds the names of the applied profiles starting with the root ancestor
+	Chain   []string
+	origins map[*ini.IniKey]string
+}
+
+//region Loading
+
+// Parse parses a profile fragment
+func Parse(name string, data string) (*Profile, error) {
+	profile := &Profile{Name: name}
+	section := ""
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("%s line %d: key outside of a section", name, i+1)
+		}
+
+		key := ini.NewParsedIniKey(line)
+		if key == nil {
+			return nil, fmt.Errorf("%s line %d: key without a name", name, i+1)
+		}
+		if strings.EqualFold(section, MetaSection) {
+			if strings.EqualFold(key.Key, "Parent") {
+				profile.Parent = strings.TrimSpace(key.ToValueString())
+			}
+			continue
+		}
+
+		override := Override{Section: section, Key: key.Key, Value: key.Value}
+		for _, operator := range []Operator{Add, AddAlways, Remove, Clear} {
+			if strings.HasPrefix(key.Key, string(operator)) {
+				override.Operator = operator
+				override.Key = key.Key[len(operator):]
+				break
+			}
+		}
+		if override.Key == "" {
+			return nil, fmt.Errorf("%s line %d: key without a name", name, i+1)
+		}
+		profile.Overrides = append(profile.Overrides, override)
+	}
+	return profile, nil
+}
+
+// LoadDir loads every .ini file of the directory as a profile named after the file without the extension
+func LoadDir(dir string) (Profiles, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(Profiles)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), Extension) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		profile, err := Parse(name, string(data))
+		if err != nil {
+			return nil, err
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// Add adds the profiles, a profile with the same name is replaced
+func (p Profiles) Add(profiles ...*Profile) {
+	for _, profile := range profiles {
+		p[profile.Name] = profile
+	}
+}
+
+// Names returns the names of all profiles sorted alphabetically
+func (p Profiles) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//endregion
+
+//region Resolving
+
+// Resolve applies the profile and all its ancestors, starting with the root ancestor, to a new file
+func (p Profiles) Resolve(name string, allowedDuplicateKeys ...string) (*Resolved, error) {
+	chain, err := p.Chain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &Resolved{File: ini.NewIniFile(allowedDuplicateKeys...), origins: make(map[*ini.IniKey]string)}
+	for _, profile := range chain {
+		resolved.Chain = append(resolved.Chain, profile.Name)
+		apply(resolved.File, profile, func(key *ini.IniKey) {
+			resolved.origins[key] = profile.Name
+		})
+	}
+	return resolved, nil
+}
+
+// Chain returns the profile and all its ancestors starting with the root ancestor
+func (p Profiles) Chain(name string) ([]*Profile, error) {
+	var chain []*Profile
+	visited := make(map[string]bool)
+	for current := name; current != ""; {
+		if visited[current] {
+			return nil, fmt.Errorf("%w: %q", ErrCycle, current)
+		}
+		visited[current] = true
+
+		profile, exists := p[current]
+		if !exists {
+			return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, current)
+		}
+		chain = append([]*Profile{profile}, chain...)
+		current = profile.Parent
+	}
+	return chain, nil
+}
+
+// Apply applies the overrides of the profile to the file, the parent of the profile is ignored
+func Apply(file *ini.IniFile, profile *Profile) {
+	apply(file, profile, func(*ini.IniKey) {})
+}
+
+// apply applies the overrides of the profile to the file and calls set for every key it sets or adds
+func apply(file *ini.IniFile, profile *Profile, set func(key *ini.IniKey)) {
+	replaced := make(map[string]bool)
+	for _, override := range profile.Overrides {
+		section := file.GetOrCreateSection(override.Section)
+		switch override.Operator {
+		case Set:
+			if !section.IsAllowedDuplicateKey(override.Key) {
+				section.AddOrReplaceKey(override.Key, override.Value)
+				key, _ := section.GetKey(override.Key)
+				set(key)
+				break
+			}
+			id := override.Section + "\x00" + override.Key
+			if !replaced[id] {
+				section.RemoveMultipleKey(override.Key)
+				replaced[id] = true
+			}
+			section.AddKey(override.Key, override.Value)
+			set(section.Keys[len(section.Keys)-1])
+		case Add:
+			if indexOfValue(section, override.Key, override.Value) >= 0 {
+				break
+			}
+			section.AddKey(override.Key, override.Value)
+			set(section.Keys[len(section.Keys)-1])
+		case AddAlways:
+			section.AddKey(override.Key, override.Value)
+			set(section.Keys[len(section.Keys)-1])
+		case Remove:
+			for i := indexOfValue(section, override.Key, override.Value); i >= 0; i = indexOfValue(section, override.Key, override.Value) {
+				section.RemoveKeyAt(i)
+			}
+		case Clear:
+			section.RemoveMultipleKey(override.Key)
+		}
+	}
+}
+
+// Origin returns the name of the profile that set the key, or false if the key was not set by a profile
+func (r *Resolved) Origin(key *ini.IniKey) (string, bool) {
+	profile, exists := r.origins[key]
+	return profile, exists
+}
+
+// Origins returns the profile that set every value of the resolved file in file order
+func (r *Resolved) Origins() []Origin {
+	var origins []Origin
+	for _, section := range r.File.Sections {
+		for _, key := range section.Keys {
+			if profile, exists := r.origins[key]; exists {
+				origins = append(origins, Origin{Section: section.SectionName, Key: key.Key, Value: key.Value, Profile: profile})
+			}
+		}
+	}
+	return origins
+}
+
+// indexOfValue returns the index of the first key with the name and value in the section, or -1 if there is none
+func indexOfValue(section *ini.IniSection, keyName string, value interface{}) int {
+	expected := ini.NewIniKey(keyName, value).ToValueString()
+	for i, key := range section.Keys {
+		if key.Key == keyName && key.ToValueString() == expected {
+			return i
+		}
+	}
+	return -1
+}
+
+//endregion
@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var fragments = map[string]string{
+	"pvp-base.ini": `[ServerSettings]
+ServerPVE=false
+XPMultiplier=1.0
+[/Script/ShooterGame.ShooterGameMode]
+OverridePlayerLevelEngramPoints=5
+OverridePlayerLevelEngramPoints=8
+PreventDinoTameClassNames="Argent_Character_BP_C"
+`,
+	"pvp-boosted-weekend.ini": `[Profile]
+Parent=pvp-base
+
+[ServerSettings]
+XPMultiplier=3.0
+[/Script/ShooterGame.ShooterGameMode]
++PreventDinoTameClassNames="Argent_Character_BP_C"
++PreventDinoTameClassNames="Rex_Character_BP_C"
+.OverridePlayerLevelEngramPoints=8
+-OverridePlayerLevelEngramPoints=5
+`,
+	"notes.txt": "not a profile",
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range fragments {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	profiles, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := profiles.Names(); !reflect.DeepEqual(names, []string{"pvp-base", "pvp-boosted-weekend"}) {
+		t.Fatalf("unexpected profiles %v", names)
+	}
+	weekend := profiles["pvp-boosted-weekend"]
+	if weekend.Parent != "pvp-base" || weekend.Overrides[1].Operator != Add || weekend.Overrides[1].Key != "PreventDinoTameClassNames" {
+		t.Errorf("unexpected profile %+v", weekend)
+	}
+
+	resolved, err := profiles.Resolve("pvp-boosted-weekend", "OverridePlayerLevelEngramPoints", "PreventDinoTameClassNames")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[ServerSettings]
+ServerPVE=false
+XPMultiplier=3
+[/Script/ShooterGame.ShooterGameMode]
+OverridePlayerLevelEngramPoints=8
+PreventDinoTameClassNames="Argent_Character_BP_C"
+PreventDinoTameClassNames="Rex_Character_BP_C"
+OverridePlayerLevelEngramPoints=8
+`
+	if output := resolved.File.ToString(); output != expected {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+	if !reflect.DeepEqual(resolved.Chain, []string{"pvp-base", "pvp-boosted-weekend"}) {
+		t.Errorf("unexpected chain %v", resolved.Chain)
+	}
+
+	var origins []string
+	for _, origin := range resolved.Origins() {
+		origins = append(origins, origin.Key+":"+origin.Profile)
+	}
+	expectedOrigins := []string{
+		"ServerPVE:pvp-base",
+		"XPMultiplier:pvp-boosted-weekend",
+		"OverridePlayerLevelEngramPoints:pvp-base",
+		"PreventDinoTameClassNames:pvp-base",
+		"PreventDinoTameClassNames:pvp-boosted-weekend",
+		"OverridePlayerLevelEngramPoints:pvp-boosted-weekend",
+	}
+	if !reflect.DeepEqual(origins, expectedOrigins) {
+		t.Errorf("unexpected origins %v", origins)
+	}
+}
+
+func TestChainErrors(t *testing.T) {
+	a, _ := Parse("a", "[Profile]\nParent=b\n")
+	b, _ := Parse("b", "[Profile]\nParent=a\n[ServerSettings]\n!ActiveMods\n")
+	c, _ := Parse("c", "[Profile]\nParent=missing\n")
+	profiles := Profiles{}
+	profiles.Add(a, b, c)
+
+	if b.Overrides[0].Operator != Clear || b.Overrides[0].Key != "ActiveMods" {
+		t.Errorf("unexpected override %+v", b.Overrides[0])
+	}
+	if _, err := profiles.Resolve("a"); !errors.Is(err, ErrCycle) {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+	if _, err := profiles.Resolve("c"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected a missing profile error, got %v", err)
+	}
+	if _, err := Parse("d", "Key=Value"); err == nil {
+		t.Error("expected an error for a key outside of a section")
+	}
+}
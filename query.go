@@ -0,0 +1,451 @@
+package ini
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query returns everything matched by path. A path selects a section, then keys of the section and then fields of nested containers, separated by "/":
+//
+//	"ServerSettings/MaxPlayers"
+//	`"/script/shootergame.shootergamemode"/OverrideNamedEngramEntries[EngramClassName="EngramEntry_CryoGun_Mod_C"]/EngramPointsCost`
+//
+// Names containing "/", "[", "]" or spaces must be quoted, "*" matches every name.
+// A segment can be followed by predicates in square brackets:
+//
+//	[Field="value"] and [Field!="value"] - keep containers whose field has (or does not have) the value, quotes around values are ignored when comparing.
+//
+//	[n] - keep only the n-th match (zero based) of the segment, e.g. "ServerSettings/Mods[1]" is the second Mods key.
+func (f *IniFile) Query(path string) ([]QueryResult, error) {
+	matches, err := f.match(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]QueryResult, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, QueryResult{
+			Section: m.section,
+			Key:     m.section.Keys[m.keyIndex],
+			Field:   strings.Join(m.fieldNames, "."),
+			Value:   m.value,
+			Type:    typeOfValue(m.value),
+		})
+	}
+	return results, nil
+}
+
+// QueryAs returns the values matched by path converted to T, see Get for the conversion rules
+func QueryAs[T Value](file *IniFile, path string) ([]T, error) {
+	results, err := file.Query(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]T, 0, len(results))
+	for _, result := range results {
+		value, err := Convert[T](result.Value)
+		if err != nil {
+			return nil, newConvertError[T](result.Section.SectionName, result.Key.Key, result.Value, fmt.Errorf("path %q field %q: %w", path, result.Field, err))
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// SetPath replaces the value of every key or container field matched by path and returns the number of replaced values.
+// Containers are replaced as a whole so the change is reported to observers as a KeySet of the key holding the container.
+func (f *IniFile) SetPath(path string, value interface{}) (int, error) {
+	matches, err := f.match(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.beginOp()()
+	for _, group := range groupMatches(matches) {
+		section, keyIndex := group[0].section, group[0].keyIndex
+		if len(group[0].fieldPath) == 0 {
+			section.setKeyAt(keyIndex, cloneValue(value), "")
+			continue
+		}
+
+		newValue := cloneValue(section.Keys[keyIndex].Value)
+		for _, m := range group {
+			newValue = modifyField(newValue, m.fieldPath, func(keyValues []ContainerKey, i int) []ContainerKey {
+				keyValues[i].Value = cloneValue(value)
+				return keyValues
+			})
+		}
+		section.setKeyAt(keyIndex, newValue, "")
+	}
+	return len(matches), nil
+}
+
+// DeletePath removes every key or container field matched by path and returns the number of removed values
+func (f *IniFile) DeletePath(path string) (int, error) {
+	matches, err := f.match(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.beginOp()()
+	groups := groupMatches(matches)
+	// Remove from the back so the indexes of the remaining matches stay valid
+	for g := len(groups) - 1; g >= 0; g-- {
+		group := groups[g]
+		section, keyIndex := group[0].section, group[0].keyIndex
+		if len(group[0].fieldPath) == 0 {
+			section.removeKeyAt(keyIndex)
+			continue
+		}
+
+		newValue := cloneValue(section.Keys[keyIndex].Value)
+		for i := len(group) - 1; i >= 0; i-- {
+			newValue = modifyField(newValue, group[i].fieldPath, func(keyValues []ContainerKey, i int) []ContainerKey {
+				return append(keyValues[:i], keyValues[i+1:]...)
+			})
+		}
+		section.setKeyAt(keyIndex, newValue, "")
+	}
+	return len(matches), nil
+}
+
+// QueryResult is a key or container field matched by a path
+type QueryResult struct {
+	// Section is the section holding the key
+	Section *IniSection
+	// Key is the matched key or the key holding the matched container field
+	Key *IniKey
+	// Field is the dotted path of the matched container field inside the value of Key, it is empty when the key itself is matched
+	Field string
+	Value interface{}
+	Type  KeyType
+}
+
+//region Matching
+
+type pathMatch struct {
+	section    *IniSection
+	keyIndex   int
+	fieldPath  []int
+	fieldNames []string
+	value      interface{}
+}
+
+// match returns the matches of path in the order they appear in the file
+func (f *IniFile) match(path string) ([]pathMatch, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("%w: %q must select a section and a key", ErrInvalidPath, path)
+	}
+	if len(segments[0].predicates) > 0 {
+		return nil, fmt.Errorf("%w: %q sections cannot have field predicates", ErrInvalidPath, path)
+	}
+
+	var matches []pathMatch
+	var sections []*IniSection
+	for _, section := range f.Sections {
+		if segments[0].matchesName(section.SectionName) {
+			sections = append(sections, section)
+		}
+	}
+	sections = selectIndex(sections, segments[0].index)
+
+	for _, section := range sections {
+		var keyMatches []pathMatch
+		for i, key := range section.Keys {
+			if segments[1].matchesName(key.Key) && segments[1].matchesPredicates(key.Value) {
+				keyMatches = append(keyMatches, pathMatch{section: section, keyIndex: i, value: key.Value})
+			}
+		}
+		matches = append(matches, selectIndex(keyMatches, segments[1].index)...)
+	}
+
+	for _, segment := range segments[2:] {
+		var fieldMatches []pathMatch
+		for _, parent := range matches {
+			keyValues, ok := containerKeysOf(parent.value)
+			if !ok {
+				continue
+			}
+			var children []pathMatch
+			for i, kv := range keyValues {
+				if segment.matchesName(kv.Key) && segment.matchesPredicates(kv.Value) {
+					children = append(children, pathMatch{
+						section:    parent.section,
+						keyIndex:   parent.keyIndex,
+						fieldPath:  append(append([]int(nil), parent.fieldPath...), i),
+						fieldNames: append(append([]string(nil), parent.fieldNames...), kv.Key),
+						value:      kv.Value,
+					})
+				}
+			}
+			fieldMatches = append(fieldMatches, selectIndex(children, segment.index)...)
+		}
+		matches = fieldMatches
+	}
+	return matches, nil
+}
+
+// groupMatches groups the matches by the key they belong to, keeping the order of the file
+func groupMatches(matches []pathMatch) [][]pathMatch {
+	var groups [][]pathMatch
+	for _, m := range matches {
+		last := len(groups) - 1
+		if last >= 0 && groups[last][0].section == m.section && groups[last][0].keyIndex == m.keyIndex {
+			groups[last] = append(groups[last], m)
+			continue
+		}
+		groups = append(groups, []pathMatch{m})
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return lessFieldPath(group[i].fieldPath, group[j].fieldPath)
+		})
+	}
+	return groups
+}
+
+func lessFieldPath(a []int, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// modifyField calls fn with the container keys holding the field at fieldPath and its index, the container keys returned by fn replace the old ones
+func modifyField(value interface{}, fieldPath []int, fn func(keyValues []ContainerKey, i int) []ContainerKey) interface{} {
+	keyValues, ok := containerKeysOf(value)
+	if !ok || fieldPath[0] >= len(keyValues) {
+		return value
+	}
+
+	if len(fieldPath) == 1 {
+		keyValues = fn(keyValues, fieldPath[0])
+	} else {
+		keyValues[fieldPath[0]].Value = modifyField(keyValues[fieldPath[0]].Value, fieldPath[1:], fn)
+	}
+
+	if _, isSlice := value.([]ContainerKey); isSlice {
+		return keyValues
+	}
+	return IniContainer{KeyValues: keyValues}
+}
+
+// containerKeysOf returns the fields of a container value
+func containerKeysOf(value interface{}) ([]ContainerKey, bool) {
+	switch v := value.(type) {
+	case IniContainer:
+		return v.KeyValues, true
+	case []ContainerKey:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// selectIndex returns only the element at index when index is not nil
+func selectIndex[T any](items []T, index *int) []T {
+	if index == nil {
+		return items
+	}
+	if *index < 0 || *index >= len(items) {
+		return nil
+	}
+	return items[*index : *index+1]
+}
+
+//endregion
+
+//region Parsing
+
+type pathSegment struct {
+	name       string
+	wildcard   bool
+	predicates []pathPredicate
+	index      *int
+}
+
+type pathPredicate struct {
+	field  string
+	value  string
+	negate bool
+}
+
+func (s pathSegment) matchesName(name string) bool {
+	return s.wildcard || s.name == name
+}
+
+// matchesPredicates returns true if value is a container that matches all predicates, or if there are no predicates
+func (s pathSegment) matchesPredicates(value interface{}) bool {
+	if len(s.predicates) == 0 {
+		return true
+	}
+	keyValues, ok := containerKeysOf(value)
+	if !ok {
+		return false
+	}
+
+	for _, predicate := range s.predicates {
+		found := false
+		for _, kv := range keyValues {
+			if kv.Key == predicate.field {
+				found = unquote(formatValue(kv.Value)) == predicate.value
+				break
+			}
+		}
+		if found == predicate.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePath splits a path into its segments
+func parsePath(path string) ([]pathSegment, error) {
+	p := pathParser{input: path}
+	var segments []pathSegment
+	for {
+		segment, err := p.segment()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+
+		if p.done() {
+			return segments, nil
+		}
+		if p.next() != '/' {
+			return nil, p.errorf("expected '/'")
+		}
+	}
+}
+
+type pathParser struct {
+	input string
+	pos   int
+}
+
+func (p *pathParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *pathParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *pathParser) next() byte {
+	c := p.peek()
+	p.pos++
+	return c
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %q at position %d: %s", ErrInvalidPath, p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *pathParser) segment() (pathSegment, error) {
+	var segment pathSegment
+	name, quoted, err := p.word("/[")
+	if err != nil {
+		return segment, err
+	}
+	if name == "" && !quoted {
+		return segment, p.errorf("expected a name")
+	}
+	segment.name = name
+	segment.wildcard = name == "*" && !quoted
+
+	for p.peek() == '[' {
+		p.next()
+		if err := p.predicate(&segment); err != nil {
+			return segment, err
+		}
+		if p.next() != ']' {
+			return segment, p.errorf("expected ']'")
+		}
+	}
+	return segment, nil
+}
+
+func (p *pathParser) predicate(segment *pathSegment) error {
+	field, quoted, err := p.word("=!]")
+	if err != nil {
+		return err
+	}
+
+	if p.peek() == ']' {
+		index, err := strconv.Atoi(field)
+		if err != nil || quoted {
+			return p.errorf("expected an index or a field predicate")
+		}
+		if segment.index != nil {
+			return p.errorf("only one index is allowed per segment")
+		}
+		segment.index = &index
+		return nil
+	}
+
+	predicate := pathPredicate{field: field}
+	if p.peek() == '!' {
+		p.next()
+		predicate.negate = true
+	}
+	if p.next() != '=' {
+		return p.errorf("expected '='")
+	}
+
+	predicate.value, _, err = p.word("]")
+	if err != nil {
+		return err
+	}
+	segment.predicates = append(segment.predicates, predicate)
+	return nil
+}
+
+// word reads a quoted string or everything until one of the stop characters
+func (p *pathParser) word(stop string) (string, bool, error) {
+	if p.peek() != '"' {
+		start := p.pos
+		for !p.done() && !strings.ContainsRune(stop, rune(p.peek())) {
+			p.next()
+		}
+		return strings.TrimSpace(p.input[start:p.pos]), false, nil
+	}
+
+	start := p.pos
+	p.next()
+	for !p.done() {
+		switch p.next() {
+		case '\\':
+			p.next()
+		case '"':
+			word, err := strconv.Unquote(p.input[start:p.pos])
+			if err != nil {
+				return "", true, p.errorf("invalid quoted string")
+			}
+			return word, true, nil
+		}
+	}
+	return "", true, p.errorf("unterminated quoted string")
+}
+
+// unquote removes the double quotes around a string value if it has them
+func unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+//endregion
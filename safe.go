@@ -0,0 +1,129 @@
+package ini
+
+import "sync"
+
+// SafeIniFile wraps an IniFile so it can be shared between goroutines.
+// Reads run under a read lock, writes run on a copy of the file which replaces the wrapped file when the write succeeds.
+type SafeIniFile struct {
+	mu        sync.RWMutex
+	file      *IniFile
+	observers []Observer
+}
+
+// NewSafeIniFile returns a new SafeIniFile wrapping file, file must not be used directly afterwards
+func NewSafeIniFile(file *IniFile) *SafeIniFile {
+	return &SafeIniFile{file: file}
+}
+
+// View calls fn with the wrapped file under a read lock, fn must not modify the file or keep references to it
+func (s *SafeIniFile) View(fn func(file *IniFile) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.file)
+}
+
+// Update calls fn with a copy of the wrapped file, if fn returns nil the copy replaces the wrapped file, otherwise all changes are discarded.
+// Updates are serialized, fn must not keep references to tx. The changes of a successful update are sent to the observers after the update is committed.
+func (s *SafeIniFile) Update(fn func(tx *IniFile) error) error {
+	events, observers, err := s.update(fn)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		for _, observer := range observers {
+			observer(event)
+		}
+	}
+	return nil
+}
+
+func (s *SafeIniFile) update(fn func(tx *IniFile) error) ([]ChangeEvent, []Observer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := s.file.Clone()
+	var events []ChangeEvent
+	unsubscribe := tx.Subscribe(func(event ChangeEvent) {
+		events = append(events, event)
+	})
+	err := fn(tx)
+	unsubscribe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.file = tx
+	return events, s.observers, nil
+}
+
+// Subscribe registers an observer that receives the changes of every successful Update, it must not be called from within an observer.
+// Observers run outside the lock so they may read the file.
+func (s *SafeIniFile) Subscribe(observer Observer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// Snapshot returns a deep copy of the wrapped file which can be used freely
+func (s *SafeIniFile) Snapshot() *IniFile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file.Clone()
+}
+
+// Replace replaces the wrapped file with file, file must not be used directly afterwards
+func (s *SafeIniFile) Replace(file *IniFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file = file
+}
+
+//region Shortcuts
+
+// GetKeyFromSection returns a copy of the key with the given name from the section with the given name
+func (s *SafeIniFile) GetKeyFromSection(sectionName string, keyName string) (*IniKey, error) {
+	var result *IniKey
+	err := s.View(func(file *IniFile) error {
+		key, err := file.GetKeyFromSection(sectionName, keyName)
+		if err != nil {
+			return err
+		}
+		result = key.Clone()
+		return nil
+	})
+	return result, err
+}
+
+// SafelyAddKeyToSection see IniFile.SafelyAddKeyToSection
+func (s *SafeIniFile) SafelyAddKeyToSection(sectionName string, keyName string, value interface{}) {
+	_ = s.Update(func(tx *IniFile) error {
+		tx.SafelyAddKeyToSection(sectionName, keyName, value)
+		return nil
+	})
+}
+
+// UpdateOrCreateKeyInSection see IniFile.UpdateOrCreateKeyInSection
+func (s *SafeIniFile) UpdateOrCreateKeyInSection(sectionName string, keyName string, value interface{}) {
+	_ = s.Update(func(tx *IniFile) error {
+		tx.UpdateOrCreateKeyInSection(sectionName, keyName, value)
+		return nil
+	})
+}
+
+// RemoveKeyFromSection see IniFile.RemoveKeyFromSection
+func (s *SafeIniFile) RemoveKeyFromSection(sectionName string, keyName string) {
+	_ = s.Update(func(tx *IniFile) error {
+		tx.RemoveKeyFromSection(sectionName, keyName)
+		return nil
+	})
+}
+
+// ToString returns the wrapped file as a string
+func (s *SafeIniFile) ToString() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file.ToString()
+}
+
+//endregion
@@ -1,12 +1,12 @@
 package ini
 
-import "errors"
-
 // IniSection represents a section in an INI file
 type IniSection struct {
 	AllowedDuplicateKeys *[]string
 	SectionName          string
 	Keys                 []*IniKey
+
+	file *IniFile
 }
 
 // NewIniSection returns a new IniSection with the given section name
@@ -24,9 +24,11 @@ func NewIniSection(sectionName string, allowedDuplicateKeys *[]string) *IniSecti
 func (s *IniSection) OverwriteKey(key string, value ...string) error {
 
 	if len(value) == 0 {
-		return errors.New("no value(s) provided")
+		return &KeyError{Section: s.SectionName, Key: key, Err: ErrNoValue}
 	}
 
+	defer s.beginOp()()
+
 	if s.IsAllowedDuplicateKey(key) {
 		s.RemoveMultipleKey(key)
 		for _, v := range value {
@@ -41,30 +43,65 @@ func (s *IniSection) OverwriteKey(key string, value ...string) error {
 
 // AddKey adds a key no matter if it already exists. (May result in duplicate keys) (it will take the fiFrst key found if there are more)
 func (s *IniSection) AddKey(keyName string, value interface{}) {
-	s.Keys = append(s.Keys, NewIniKey(keyName, value))
+	defer s.beginOp()()
+	s.insertKeyAt(len(s.Keys), NewIniKey(keyName, value))
 }
 
 // AddOrReplaceKey adds a key if it not exists otherwise it will replace it (it will take the first key found if there are more) (Use this to avoid duplicate keys)
 func (s *IniSection) AddOrReplaceKey(keyName string, value interface{}) {
-	for _, key := range s.Keys {
+	defer s.beginOp()()
+	for i, key := range s.Keys {
 		if key.Key == keyName {
-			key.Value = value
+			s.setKeyAt(i, value, "")
 			return
 		}
 	}
-	s.Keys = append(s.Keys, NewIniKey(keyName, value))
+	s.AddKey(keyName, value)
 }
 
-// AddParsedKey adds a key from a string like “key=value”, no matter if it already exists (it will take the first key found if there are more)
+// AddParsedKey adds a key from a string like “key=value”, no matter if it already exists (it will take the first key found if there are more).
+// The value is written as it was parsed, see IniKey.ToValueString
 func (s *IniSection) AddParsedKey(keyString string) {
 	key := NewParsedIniKey(keyString)
-	s.AddKey(key.Key, key.Value)
+	defer s.beginOp()()
+	s.insertKeyAt(len(s.Keys), key)
 }
 
-// AddOrReplaceParsedKey adds a key from a string like “key=value” if it does not exist otherwise it will replace it (it will take the first key found if there are more) (Use this to avoid duplicate keys)
+// AddOrReplaceParsedKey adds a key from a string like “key=value” if it does not exist otherwise it will replace it (it will take the first key found if there are more) (Use this to avoid duplicate keys).
+// The value is written as it was parsed, see IniKey.ToValueString
 func (s *IniSection) AddOrReplaceParsedKey(keyString string) {
 	key := NewParsedIniKey(keyString)
-	s.AddOrReplaceKey(key.Key, key.Value)
+	defer s.beginOp()()
+	for i, existing := range s.Keys {
+		if existing.Key == key.Key {
+			s.setKeyAt(i, key.Value, key.Raw)
+			return
+		}
+	}
+	s.insertKeyAt(len(s.Keys), key)
+}
+
+// EditContainerKey calls fn with a copy of the container value of the first key with the given name, if fn returns nil the edited copy replaces the value of the key
+func (s *IniSection) EditContainerKey(keyName string, fn func(container *IniContainer) error) error {
+	for i, key := range s.Keys {
+		if key.Key != keyName {
+			continue
+		}
+
+		container, err := key.AsContainer()
+		if err != nil {
+			return err
+		}
+		container = container.Clone()
+		if err := fn(&container); err != nil {
+			return err
+		}
+
+		defer s.beginOp()()
+		s.setKeyAt(i, container, "")
+		return nil
+	}
+	return &KeyError{Section: s.SectionName, Key: keyName, Err: ErrKeyNotFound}
 }
 
 //endregion
@@ -100,7 +137,8 @@ func (s *IniSection) GetMultipleKeys(keyName string) []*IniKey {
 func (s *IniSection) RemoveKey(keyName string) {
 	for i, key := range s.Keys {
 		if key.Key == keyName {
-			s.Keys = append(s.Keys[:i], s.Keys[i+1:]...)
+			defer s.beginOp()()
+			s.removeKeyAt(i)
 			return
 		}
 	}
@@ -108,18 +146,67 @@ func (s *IniSection) RemoveKey(keyName string) {
 
 // RemoveMultipleKey removes all the keys with the same Key
 func (s *IniSection) RemoveMultipleKey(keyName string) {
-	for i, key := range s.Keys {
-		if key.Key == keyName {
-			s.Keys = append(s.Keys[:i], s.Keys[i+1:]...)
+	defer s.beginOp()()
+	for i := len(s.Keys) - 1; i >= 0; i-- {
+		if s.Keys[i].Key == keyName {
+			s.removeKeyAt(i)
 		}
 	}
 }
 
 // RemoveAllKeys removes all keys from the section
 func (s *IniSection) RemoveAllKeys() {
+	defer s.beginOp()()
+	for i := len(s.Keys) - 1; i >= 0; i-- {
+		s.removeKeyAt(i)
+	}
 	s.Keys = make([]*IniKey, 0)
 }
 
+// RemoveKeyAt removes the key at index i of Keys and returns false if there is no such key
+func (s *IniSection) RemoveKeyAt(i int) bool {
+	if i < 0 || i >= len(s.Keys) {
+		return false
+	}
+	defer s.beginOp()()
+	s.removeKeyAt(i)
+	return true
+}
+
+// InsertKeyAt inserts a key at index i of Keys and returns false if i is out of range, an index of len(Keys) appends the key
+func (s *IniSection) InsertKeyAt(i int, keyName string, value interface{}) bool {
+	if i < 0 || i > len(s.Keys) {
+		return false
+	}
+	defer s.beginOp()()
+	s.insertKeyAt(i, NewIniKey(keyName, value))
+	return true
+}
+
+// removeKeyAt removes the key at index i from the section
+func (s *IniSection) removeKeyAt(i int) {
+	key := s.Keys[i]
+	s.Keys = append(s.Keys[:i], s.Keys[i+1:]...)
+	if s.file != nil {
+		s.file.emit(ChangeEvent{Type: KeyRemoved, Section: s.SectionName, Key: key.Key, Index: i, OldValue: key.Value, section: s, oldRaw: key.Raw})
+	}
+}
+
+// insertKeyAt inserts the key at index i in the section
+func (s *IniSection) insertKeyAt(i int, key *IniKey) {
+	s.Keys = append(s.Keys[:i], append([]*IniKey{key}, s.Keys[i:]...)...)
+	s.emitKeyEvent(KeyAdded, key.Key, i, nil, key.Value, "", key.Raw)
+}
+
+// setKeyAt replaces the value of the key at index i in the section, raw is the text the value was parsed from or empty
+func (s *IniSection) setKeyAt(i int, value interface{}, raw string) {
+	key := s.Keys[i]
+	oldValue, oldRaw := key.Value, key.Raw
+	key.Value = value
+	key.Raw = raw
+	s.emitKeyEvent(KeySet, key.Key, i, oldValue, value, oldRaw, raw)
+}
+
 // FindKey returns the key with the given name and true, or nil and false if it doesn't exist
 func (s *IniSection) FindKey(keyName string) (*IniKey, bool) {
 	for _, key := range s.Keys {
@@ -140,7 +227,7 @@ func (s *IniSection) FindKeys(keyName string) ([]*IniKey, error) {
 	}
 
 	if len(keys) == 0 {
-		return nil, errors.New("no matching keys found")
+		return nil, &KeyError{Section: s.SectionName, Key: keyName, Err: ErrKeyNotFound}
 	}
 
 	return keys, nil
@@ -1,6 +1,7 @@
 package ini
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -15,8 +16,13 @@ func SerializeIniFile(file *IniFile) string {
 	return result
 }*/
 
-// DeserializeIniFile converts INI format string to IniFile
+// DeserializeIniFile converts INI format string to IniFile, the types of the values are guessed
 func DeserializeIniFile(data string, allowedDuplicateKeys ...string) (*IniFile, error) {
+	return DeserializeIniFileWithOptions(data, ParseOptions{}, allowedDuplicateKeys...)
+}
+
+// DeserializeIniFileWithOptions converts INI format string to IniFile, the values are parsed according to options
+func DeserializeIniFileWithOptions(data string, options ParseOptions, allowedDuplicateKeys ...string) (*IniFile, error) {
 	// Initialize an empty IniFile
 	file := NewIniFile(allowedDuplicateKeys...)
 	var currentSection *IniSection
@@ -38,7 +44,7 @@ func DeserializeIniFile(data string, allowedDuplicateKeys ...string) (*IniFile,
 			if strings.HasPrefix(currentLine, "[") && strings.HasSuffix(currentLine, "]") {
 				// Extract the section name and create a new section
 				sectionName := strings.TrimPrefix(strings.TrimSuffix(currentLine, "]"), "[")
-				currentSection = NewIniSection(sectionName, &file.AllowedDuplicateKeys)
+				currentSection = file.newSection(sectionName)
 				// Add the new section to the IniFile
 				file.Sections = append(file.Sections, currentSection)
 			} else if currentSection != nil {
@@ -52,14 +58,16 @@ func DeserializeIniFile(data string, allowedDuplicateKeys ...string) (*IniFile,
 					// If the value is a container
 					if strings.HasPrefix(value, "(") {
 						// Keep appending lines to the value until the closing parenthesis is found
-						for !strings.HasSuffix(value, ")") && i < len(data) {
+						for !strings.HasSuffix(value, ")") && i+1 < len(data) {
 							i++
 							value += string(data[i])
 						}
 					}
 
 					// Add the key-value pair to the current section
-					currentSection.AddKey(key, toGuessedType(value))
+					if err := addParsedValue(currentSection, key, value, options); err != nil {
+						return nil, err
+					}
 				}
 			}
 			// Reset the current line
@@ -78,7 +86,10 @@ func DeserializeIniFile(data string, allowedDuplicateKeys ...string) (*IniFile,
 			// If the line is a key-value pair
 			if strings.Contains(currentLine, "=") {
 				// Add the key-value pair to the current section
-				currentSection.AddParsedKey(currentLine)
+				keyValuePair := strings.SplitN(currentLine, "=", 2)
+				if err := addParsedValue(currentSection, keyValuePair[0], keyValuePair[1], options); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
@@ -87,7 +98,21 @@ func DeserializeIniFile(data string, allowedDuplicateKeys ...string) (*IniFile,
 	return file, nil
 }
 
-// ToMap converts an IniFile to a map[string]map[string][]string
+// addParsedValue parses value according to options and adds it to section as a key with the given name
+func addParsedValue(section *IniSection, keyName string, value string, options ParseOptions) error {
+	parser := valueParser{options: options, sectionName: section.SectionName, keyName: keyName}
+	parsedValue, err := parser.parseKey(value)
+	if err != nil {
+		return &KeyError{Section: section.SectionName, Key: keyName, Err: err}
+	}
+
+	key := NewIniKey(keyName, parsedValue)
+	key.Raw = value
+	section.Keys = append(section.Keys, key)
+	return nil
+}
+
+// ToMap converts an IniFile to a map[string]map[string][]string, use ToOrderedMap to keep the order of the file
 func ToMap(file *IniFile) map[string]map[string][]string {
 	result := make(map[string]map[string][]string)
 	for _, section := range file.Sections {
@@ -100,17 +125,121 @@ func ToMap(file *IniFile) map[string]map[string][]string {
 	return result
 }
 
-// DeserializeFromMap converts a map[string]map[string][]string to an IniFile
+// DeserializeFromMap converts a map[string]map[string][]string to an IniFile. Sections and keys are added in alphabetical order so the result is the same on every run.
 func DeserializeFromMap(data map[string]map[string][]string, allowedDuplicateKeys ...string) *IniFile {
+	return DeserializeFromOrderedMap(orderMap(data, nil), allowedDuplicateKeys...)
+}
+
+// DeserializeFromMapWithReference converts a map[string]map[string][]string to an IniFile following the section and key order of reference. Sections and keys that do not appear in reference are appended in alphabetical order.
+func DeserializeFromMapWithReference(data map[string]map[string][]string, reference *IniFile, allowedDuplicateKeys ...string) *IniFile {
+	return DeserializeFromOrderedMap(orderMap(data, reference), allowedDuplicateKeys...)
+}
+
+//region Ordered map
+
+// OrderedSection is a section of an OrderedMap, it keeps the order of its keys
+type OrderedSection struct {
+	SectionName string
+	Keys        []OrderedKey
+}
+
+// OrderedKey is a key of an OrderedSection with all of its values
+type OrderedKey struct {
+	Key    string
+	Values []string
+}
+
+// OrderedMap is the ordered counterpart of the map returned by ToMap
+type OrderedMap []OrderedSection
+
+// ToOrderedMap converts an IniFile to an OrderedMap, sections and keys keep the order of the file (duplicate keys are grouped at the position of the first one)
+func ToOrderedMap(file *IniFile) OrderedMap {
+	result := make(OrderedMap, 0, len(file.Sections))
+	for _, section := range file.Sections {
+		orderedSection := OrderedSection{SectionName: section.SectionName}
+		positions := make(map[string]int)
+		for _, key := range section.Keys {
+			if i, exists := positions[key.Key]; exists {
+				orderedSection.Keys[i].Values = append(orderedSection.Keys[i].Values, key.ToValueString())
+				continue
+			}
+			positions[key.Key] = len(orderedSection.Keys)
+			orderedSection.Keys = append(orderedSection.Keys, OrderedKey{Key: key.Key, Values: []string{key.ToValueString()}})
+		}
+		result = append(result, orderedSection)
+	}
+	return result
+}
+
+// DeserializeFromOrderedMap converts an OrderedMap to an IniFile keeping the order of the sections and keys
+func DeserializeFromOrderedMap(data OrderedMap, allowedDuplicateKeys ...string) *IniFile {
 	file := NewIniFile(allowedDuplicateKeys...)
-	for sectionName, sectionData := range data {
-		section := NewIniSection(sectionName, &file.AllowedDuplicateKeys)
-		for keyName, values := range sectionData {
-			for _, value := range values {
-				section.AddKey(keyName, toGuessedType(value))
+	for _, sectionData := range data {
+		section := file.newSection(sectionData.SectionName)
+		for _, key := range sectionData.Keys {
+			for _, value := range key.Values {
+				section.AddKey(key.Key, toGuessedType(value))
 			}
 		}
 		file.Sections = append(file.Sections, section)
 	}
 	return file
 }
+
+// orderMap converts data to an OrderedMap, using the order of reference when it is not nil and alphabetical order for everything else
+func orderMap(data map[string]map[string][]string, reference *IniFile) OrderedMap {
+	var sectionOrder []string
+	keyOrder := make(map[string][]string)
+	if reference != nil {
+		for _, section := range reference.Sections {
+			sectionOrder = append(sectionOrder, section.SectionName)
+			for _, key := range section.Keys {
+				keyOrder[section.SectionName] = append(keyOrder[section.SectionName], key.Key)
+			}
+		}
+	}
+
+	result := make(OrderedMap, 0, len(data))
+	for _, sectionName := range orderNames(mapKeys(data), sectionOrder) {
+		sectionData := data[sectionName]
+		section := OrderedSection{SectionName: sectionName}
+		for _, keyName := range orderNames(mapKeys(sectionData), keyOrder[sectionName]) {
+			section.Keys = append(section.Keys, OrderedKey{Key: keyName, Values: sectionData[keyName]})
+		}
+		result = append(result, section)
+	}
+	return result
+}
+
+// orderNames returns names ordered by their first position in reference, names missing from reference are sorted alphabetically and put last
+func orderNames(names []string, reference []string) []string {
+	positions := make(map[string]int)
+	for i, name := range reference {
+		if _, exists := positions[name]; !exists {
+			positions[name] = i
+		}
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		pi, iKnown := positions[names[i]]
+		pj, jKnown := positions[names[j]]
+		if iKnown && jKnown {
+			return pi < pj
+		}
+		if iKnown != jKnown {
+			return iKnown
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+//endregion
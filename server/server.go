@@ -0,0 +1,797 @@
+// Package server exposes the ini files of a directory over a local HTTP/JSON API, e.g. ShooterGame/Saved/Config/WindowsServer.
+//
+//	GET    /files                                      list the .ini files
+//	GET    /files/{file}                               all sections and keys
+//	PUT    /files/{file}                               replace the file with the ini text of the body
+//	GET    /files/{file}/sections                      list the section names
+//	GET    /files/{file}/sections/{section}            the keys of the section
+//	DELETE /files/{file}/sections/{section}            remove the section
+//	GET    /files/{file}/sections/{section}/keys/{key} the values of the key
+//	PUT    /files/{file}/sections/{section}/keys/{key} set the values of the key, {"values": ["70"]}
+//	PATCH  /files/{file}/sections/{section}/keys/{key} set or remove fields of a container key, {"fields": {"Quantity.MaxItemQuantity": "5", "Removed": null}}
+//	DELETE /files/{file}/sections/{section}/keys/{key} remove the key
+//	POST   /files/{file}/validate                      check the ini text of the body, or the file if the body is empty
+//	POST   /files/{file}/diff                          the differences between the file and the ini text of the body
+//
+// Names containing "/" like /Script/ShooterGame.ShooterGameMode must be escaped as %2F. The key endpoints take the query parameters
+// index, to select one value of a key that appears more than once, and field, the dotted path of a container field e.g. Quantity.MaxItemQuantity.
+// Values are sent and returned as the strings written in the file, types are guessed like the parser does.
+//
+// Every response for a file carries an ETag, the sha256 of the bytes of the file. Requests that change an existing file must send it in If-Match so an edit
+// based on an outdated file fails with 412 Precondition Failed instead of overwriting the changes of someone else, even if they only changed a comment.
+//
+// A change rewrites the whole file the way IniFile.ToString writes it: comments and blank lines are removed,
+// values that are not changed keep the text they were written with e.g. True or 1.0.
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+// maxBodySize limits the size of request bodies, config files are far smaller
+const maxBodySize = 8 << 20
+
+var (
+	// ErrPreconditionRequired is returned when a request changes an existing file without If-Match
+	ErrPreconditionRequired = errors.New("If-Match header is required to change an existing file")
+	// ErrPreconditionFailed is returned when If-Match does not match the ETag of the file
+	ErrPreconditionFailed = errors.New("file was changed since it was read")
+	// ErrInvalidFileName is returned for file names that are not a .ini file in the directory
+	ErrInvalidFileName = errors.New("invalid file name")
+)
+
+// Server serves the ini files of a directory, it implements http.Handler
+type Server struct {
+	dir                  string
+	allowedDuplicateKeys []string
+	// mu serializes the changes so the If-Match check and the save happen together
+	mu sync.Mutex
+}
+
+// Section is the JSON form of a section
+type Section struct {
+	Name string `json:"name"`
+	Keys []Key  `json:"keys"`
+}
+
+// Key is the JSON form of a key with all of its values
+type Key struct {
+	Section string   `json:"section,omitempty"`
+	Key     string   `json:"key"`
+	Field   string   `json:"field,omitempty"`
+	Values  []string `json:"values"`
+}
+
+// Difference is the JSON form of an ini.Difference
+type Difference struct {
+	Type     ini.ChangeType `json:"type"`
+	Section  string         `json:"section"`
+	Key      string         `json:"key,omitempty"`
+	Index    int            `json:"index"`
+	OldValue *string        `json:"oldValue,omitempty"`
+	NewValue *string        `json:"newValue,omitempty"`
+}
+
+// Validation is the result of the validate endpoint
+type Validation struct {
+	Valid    bool      `json:"valid"`
+	Problems []Problem `json:"problems"`
+}
+
+// Problem is a line the game would ignore or read differently than intended
+type Problem struct {
+	// Line starts at 1, it is 0 if the problem is not about a single line
+	Line    int    `json:"line,omitempty"`
+	Section string `json:"section,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+// New returns a server for the ini files in dir, the duplicate keys are allowed in every file
+func New(dir string, allowedDuplicateKeys ...string) *Server {
+	return &Server{dir: dir, allowedDuplicateKeys: allowedDuplicateKeys}
+}
+
+//region Routing
+
+// ServeHTTP routes the request to the endpoint of its path and method
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments, err := splitPath(r.URL.EscapedPath())
+	if err != nil || len(segments) == 0 || segments[0] != "files" {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	switch {
+	case len(segments) == 1:
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.listFiles})
+	case len(segments) == 2:
+		s.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: s.withFile(segments[1], s.getFile),
+			http.MethodPut: s.withFile(segments[1], s.putFile),
+		})
+	case len(segments) == 3 && segments[2] == "sections":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodGet: s.withFile(segments[1], s.listSections)})
+	case len(segments) == 3 && segments[2] == "validate":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodPost: s.withFile(segments[1], s.validate)})
+	case len(segments) == 3 && segments[2] == "diff":
+		s.route(w, r, map[string]http.HandlerFunc{http.MethodPost: s.withFile(segments[1], s.diff)})
+	case len(segments) == 4 && segments[2] == "sections":
+		section := segments[3]
+		s.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet:    s.withFile(segments[1], func(w http.ResponseWriter, r *http.Request, f *file) { s.getSection(w, f, section) }),
+			http.MethodDelete: s.withFile(segments[1], func(w http.ResponseWriter, r *http.Request, f *file) { s.deleteSection(w, r, f, section) }),
+		})
+	case len(segments) == 6 && segments[2] == "sections" && segments[4] == "keys":
+		section, key := segments[3], segments[5]
+		handler := func(fn func(w http.ResponseWriter, r *http.Request, f *file, section string, key string)) http.HandlerFunc {
+			return s.withFile(segments[1], func(w http.ResponseWriter, r *http.Request, f *file) { fn(w, r, f, section, key) })
+		}
+		s.route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet:    handler(s.getKey),
+			http.MethodPut:    handler(s.putKey),
+			http.MethodPatch:  handler(s.patchKey),
+			http.MethodDelete: handler(s.deleteKey),
+		})
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// route calls the handler of the request method, or responds with 405 and the allowed methods
+func (s *Server) route(w http.ResponseWriter, r *http.Request, handlers map[string]http.HandlerFunc) {
+	if handler, exists := handlers[r.Method]; exists {
+		handler(w, r)
+		return
+	}
+	methods := make([]string, 0, len(handlers))
+	for method := range handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+}
+
+// splitPath splits the escaped path into its unescaped segments, so %2F inside a segment is kept as "/"
+func splitPath(escapedPath string) ([]string, error) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(escapedPath, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, unescaped)
+	}
+	return segments, nil
+}
+
+//endregion
+
+//region Files
+
+// file is a file of the directory as it was read for a request
+type file struct {
+	name string
+	path string
+	// ini is nil if the file does not exist
+	ini  *ini.IniFile
+	etag string
+}
+
+// withFile reads the file for the handler, changes are serialized so the file can't change between the If-Match check and the save
+func (s *Server) withFile(name string, handler func(w http.ResponseWriter, r *http.Request, f *file)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+		}
+
+		f, err := s.readFile(name)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		if f.ini == nil && (r.Method == http.MethodGet || r.Method == http.MethodDelete || r.Method == http.MethodPatch) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("file %q: %w", name, os.ErrNotExist))
+			return
+		}
+		handler(w, r, f)
+	}
+}
+
+func (s *Server) readFile(name string) (*file, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") || !strings.EqualFold(filepath.Ext(name), ".ini") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFileName, name)
+	}
+
+	f := &file{name: name, path: filepath.Join(s.dir, name)}
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.ini, err = ini.DeserializeIniFile(string(data), s.allowedDuplicateKeys...)
+	if err != nil {
+		return nil, err
+	}
+	f.etag = etagOf(data)
+	return f, nil
+}
+
+// save checks If-Match and writes the file, the new ETag is set on the response.
+// The file is only written if its bytes are still the ones that were read, so a change by the game server in the meantime isn't overwritten either.
+func (s *Server) save(w http.ResponseWriter, r *http.Request, f *file, content *ini.IniFile) error {
+	if err := checkPrecondition(r, f); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if f.ini != nil {
+			return fmt.Errorf("%w: %s was removed", ini.ErrConflict, f.name)
+		}
+	case err != nil:
+		return err
+	case f.ini == nil:
+		return fmt.Errorf("%w: %s already exists", ini.ErrConflict, f.name)
+	case etagOf(data) != f.etag:
+		return fmt.Errorf("%w: %s was changed", ini.ErrConflict, f.name)
+	}
+	if err := ini.SaveFile(f.path, content); err != nil {
+		return err
+	}
+	f.ini = content
+	f.etag = etagOf([]byte(content.ToString()))
+	w.Header().Set("ETag", f.etag)
+	return nil
+}
+
+// checkPrecondition requires an If-Match matching the ETag when the file exists, "*" matches any existing file
+func checkPrecondition(r *http.Request, f *file) error {
+	ifMatch := r.Header.Get("If-Match")
+	if f.ini == nil {
+		if ifMatch != "" {
+			return ErrPreconditionFailed
+		}
+		return nil
+	}
+	if ifMatch == "" {
+		return ErrPreconditionRequired
+	}
+	for _, etag := range strings.Split(ifMatch, ",") {
+		etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+		if etag == "*" || etag == f.etag {
+			return nil
+		}
+	}
+	return ErrPreconditionFailed
+}
+
+// etagOf returns the sha256 of the bytes of the file as ETag, so any change on disk invalidates it
+func etagOf(data []byte) string {
+	hash := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(hash[:]) + `"`
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && strings.EqualFold(filepath.Ext(entry.Name()), ".ini") {
+			files = append(files, entry.Name())
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"files": files})
+}
+
+func (s *Server) getFile(w http.ResponseWriter, r *http.Request, f *file) {
+	sections := make([]Section, 0, len(f.ini.Sections))
+	for _, section := range f.ini.Sections {
+		sections = append(sections, sectionOf(section))
+	}
+	w.Header().Set("ETag", f.etag)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"file": f.name, "sections": sections})
+}
+
+func (s *Server) putFile(w http.ResponseWriter, r *http.Request, f *file) {
+	content, ok := s.readIniBody(w, r)
+	if !ok {
+		return
+	}
+	status := http.StatusOK
+	if f.ini == nil {
+		status = http.StatusCreated
+	}
+	if err := s.save(w, r, f, content); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, status, map[string]string{"file": f.name})
+}
+
+func (s *Server) validate(w http.ResponseWriter, r *http.Request, f *file) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		if f.ini == nil {
+			writeError(w, http.StatusNotFound, fmt.Errorf("file %q: %w", f.name, os.ErrNotExist))
+			return
+		}
+		data, err = os.ReadFile(f.path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	problems := s.lint(string(data))
+	if _, err := ini.DeserializeIniFile(string(data), s.allowedDuplicateKeys...); err != nil {
+		problem := Problem{Message: err.Error()}
+		var keyErr *ini.KeyError
+		if errors.As(err, &keyErr) {
+			problem.Section, problem.Key = keyErr.Section, keyErr.Key
+		}
+		problems = append(problems, problem)
+	}
+	writeJSON(w, http.StatusOK, Validation{Valid: len(problems) == 0, Problems: problems})
+}
+
+// lint returns the lines of the ini text the parser would drop or the game would read differently than intended
+func (s *Server) lint(text string) []Problem {
+	problems := []Problem{}
+	lines := strings.Split(text, "\n")
+	section := ""
+	seen := make(map[string]bool)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+
+		keyName, value, found := strings.Cut(line, "=")
+		problem := Problem{Line: i + 1, Section: section, Key: keyName}
+		switch {
+		case !found:
+			problem.Key = ""
+			problem.Message = "line is not a section, a key or a comment"
+		case section == "":
+			problem.Message = "key outside of a section"
+		case seen[section+"\x00"+keyName] && !s.isAllowedDuplicateKey(keyName):
+			problem.Message = "key appears more than once but is not an allowed duplicate key, only one value is kept"
+		}
+		seen[section+"\x00"+keyName] = true
+
+		// Containers can span several lines
+		start := i
+		for depth := strings.Count(value, "(") - strings.Count(value, ")"); depth > 0 && i+1 < len(lines); {
+			i++
+			depth += strings.Count(lines[i], "(") - strings.Count(lines[i], ")")
+			value += lines[i]
+		}
+		if problem.Message == "" && strings.Count(value, "(") != strings.Count(value, ")") {
+			problem.Line = start + 1
+			problem.Message = "unbalanced parentheses in container"
+		}
+		if problem.Message != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+func (s *Server) isAllowedDuplicateKey(keyName string) bool {
+	for _, allowed := range s.allowedDuplicateKeys {
+		if allowed == keyName {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) diff(w http.ResponseWriter, r *http.Request, f *file) {
+	proposed, ok := s.readIniBody(w, r)
+	if !ok {
+		return
+	}
+	current := f.ini
+	if current == nil {
+		current = ini.NewIniFile(s.allowedDuplicateKeys...)
+	}
+
+	differences := []Difference{}
+	for _, difference := range ini.Diff(current, proposed) {
+		differences = append(differences, Difference{
+			Type:     difference.Type,
+			Section:  difference.Section,
+			Key:      difference.Key,
+			Index:    difference.Index,
+			OldValue: formatValue(difference.OldValue),
+			NewValue: formatValue(difference.NewValue),
+		})
+	}
+	if f.etag != "" {
+		w.Header().Set("ETag", f.etag)
+	}
+	writeJSON(w, http.StatusOK, map[string][]Difference{"differences": differences})
+}
+
+// readIniBody parses the body as ini text, it writes the error response and returns false if that fails
+func (s *Server) readIniBody(w http.ResponseWriter, r *http.Request) (*ini.IniFile, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return nil, false
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return ini.NewIniFile(s.allowedDuplicateKeys...), true
+	}
+	content, err := ini.DeserializeIniFile(string(data), s.allowedDuplicateKeys...)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return content, true
+}
+
+//endregion
+
+//region Sections
+
+func (s *Server) listSections(w http.ResponseWriter, r *http.Request, f *file) {
+	names := make([]string, 0, len(f.ini.Sections))
+	for _, section := range f.ini.Sections {
+		names = append(names, section.SectionName)
+	}
+	w.Header().Set("ETag", f.etag)
+	writeJSON(w, http.StatusOK, map[string][]string{"sections": names})
+}
+
+func (s *Server) getSection(w http.ResponseWriter, f *file, sectionName string) {
+	section, exists := f.ini.GetSection(sectionName)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %q", ini.ErrSectionNotFound, sectionName))
+		return
+	}
+	w.Header().Set("ETag", f.etag)
+	writeJSON(w, http.StatusOK, sectionOf(section))
+}
+
+func (s *Server) deleteSection(w http.ResponseWriter, r *http.Request, f *file, sectionName string) {
+	content := f.ini.Clone()
+	if _, exists := content.GetSection(sectionName); !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %q", ini.ErrSectionNotFound, sectionName))
+		return
+	}
+	content.RemoveSection(sectionName)
+	if err := s.save(w, r, f, content); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sectionOf(section *ini.IniSection) Section {
+	result := Section{Name: section.SectionName, Keys: []Key{}}
+	positions := make(map[string]int)
+	for _, key := range section.Keys {
+		if i, exists := positions[key.Key]; exists {
+			result.Keys[i].Values = append(result.Keys[i].Values, key.ToValueString())
+			continue
+		}
+		positions[key.Key] = len(result.Keys)
+		result.Keys = append(result.Keys, Key{Key: key.Key, Values: []string{key.ToValueString()}})
+	}
+	return result
+}
+
+//endregion
+
+//region Keys
+
+// keyRequest is the body of PUT and PATCH on a key
+type keyRequest struct {
+	// Values replace the values of the key, or of the field, for PUT
+	Values []string `json:"values"`
+	// Fields are set on the container for PATCH, a null value removes the field
+	Fields map[string]*string `json:"fields"`
+}
+
+func (s *Server) getKey(w http.ResponseWriter, r *http.Request, f *file, sectionName string, keyName string) {
+	path, err := keyPath(r, sectionName, keyName)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	results, err := f.ini.Query(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(results) == 0 {
+		writeError(w, http.StatusNotFound, notFound(sectionName, keyName, r))
+		return
+	}
+
+	key := Key{Section: results[0].Section.SectionName, Key: results[0].Key.Key, Field: r.URL.Query().Get("field"), Values: []string{}}
+	for _, result := range results {
+		key.Values = append(key.Values, *formatValue(result.Value))
+	}
+	w.Header().Set("ETag", f.etag)
+	writeJSON(w, http.StatusOK, key)
+}
+
+// putKey replaces all values of the key in place, or with index or field only the selected value, a key that doesn't exist is created
+func (s *Server) putKey(w http.ResponseWriter, r *http.Request, f *file, sectionName string, keyName string) {
+	var request keyRequest
+	if err := readJSONBody(w, r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(request.Values) == 0 {
+		writeError(w, http.StatusBadRequest, ini.ErrNoValue)
+		return
+	}
+	content := s.contentOf(f)
+	query := r.URL.Query()
+
+	if query.Has("index") || query.Has("field") {
+		if len(request.Values) != 1 {
+			writeError(w, http.StatusBadRequest, errors.New("exactly one value is required when index or field is set"))
+			return
+		}
+		path, err := keyPath(r, sectionName, keyName)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		count, err := content.SetPath(path, parseValue(request.Values[0]))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if count == 0 {
+			writeError(w, http.StatusNotFound, notFound(sectionName, keyName, r))
+			return
+		}
+	} else {
+		section := content.GetOrCreateSection(sectionName)
+		if len(request.Values) > 1 && !section.IsAllowedDuplicateKey(keyName) {
+			writeError(w, http.StatusBadRequest, &ini.KeyError{Section: sectionName, Key: keyName, Err: errors.New("key may only appear once")})
+			return
+		}
+		values := make([]interface{}, 0, len(request.Values))
+		for _, value := range request.Values {
+			values = append(values, parseValue(value))
+		}
+		fields.ReplaceKeys(section, keyName, values)
+	}
+
+	if err := s.save(w, r, f, content); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	s.getKey(w, r, f, sectionName, keyName)
+}
+
+// patchKey sets and removes fields of the container value of the key, the key must appear once or index must be set
+func (s *Server) patchKey(w http.ResponseWriter, r *http.Request, f *file, sectionName string, keyName string) {
+	var request keyRequest
+	if err := readJSONBody(w, r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(request.Fields) == 0 {
+		writeError(w, http.StatusBadRequest, ini.ErrNoValue)
+		return
+	}
+
+	content := f.ini.Clone()
+	section, exists := content.GetSection(sectionName)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %q", ini.ErrSectionNotFound, sectionName))
+		return
+	}
+	keys := section.GetMultipleKeys(keyName)
+	index, err := indexOf(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if index < 0 && len(keys) > 1 {
+		writeError(w, http.StatusBadRequest, &ini.KeyError{Section: sectionName, Key: keyName, Err: fmt.Errorf("key appears %d times, set index", len(keys))})
+		return
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(keys) {
+		writeError(w, http.StatusNotFound, notFound(sectionName, keyName, r))
+		return
+	}
+
+	names := make([]string, 0, len(request.Fields))
+	for name := range request.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	err = keys[index].EditContainer(func(container *ini.IniContainer) error {
+		for _, name := range names {
+			if request.Fields[name] == nil {
+				if err := container.Remove(name); err != nil && !errors.Is(err, ini.ErrKeyNotFound) {
+					return err
+				}
+				continue
+			}
+			if err := container.Set(name, parseValue(*request.Fields[name])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.save(w, r, f, content); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	s.getKey(w, r, f, sectionName, keyName)
+}
+
+// deleteKey removes all values of the key, or with index or field only the selected value
+func (s *Server) deleteKey(w http.ResponseWriter, r *http.Request, f *file, sectionName string, keyName string) {
+	path, err := keyPath(r, sectionName, keyName)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	content := f.ini.Clone()
+	count, err := content.DeletePath(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if count == 0 {
+		writeError(w, http.StatusNotFound, notFound(sectionName, keyName, r))
+		return
+	}
+
+	if err := s.save(w, r, f, content); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// contentOf returns a copy of the file to change, or a new file if it doesn't exist
+func (s *Server) contentOf(f *file) *ini.IniFile {
+	if f.ini == nil {
+		return ini.NewIniFile(s.allowedDuplicateKeys...)
+	}
+	return f.ini.Clone()
+}
+
+// keyPath returns the query path of the key, with the index and field of the request
+func keyPath(r *http.Request, sectionName string, keyName string) (string, error) {
+	path := strconv.Quote(sectionName) + "/" + strconv.Quote(keyName)
+	index, err := indexOf(r)
+	if err != nil {
+		return "", err
+	}
+	if index >= 0 {
+		path += "[" + strconv.Itoa(index) + "]"
+	}
+	if field := r.URL.Query().Get("field"); field != "" {
+		for _, name := range strings.Split(field, ".") {
+			path += "/" + strconv.Quote(name)
+		}
+	}
+	return path, nil
+}
+
+// indexOf returns the index query parameter, or -1 if it is not set
+func indexOf(r *http.Request) (int, error) {
+	if !r.URL.Query().Has("index") {
+		return -1, nil
+	}
+	index, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil || index < 0 {
+		return -1, fmt.Errorf("invalid index %q", r.URL.Query().Get("index"))
+	}
+	return index, nil
+}
+
+// notFound returns the error for a key, or the value selected by index and field, that doesn't exist
+func notFound(sectionName string, keyName string, r *http.Request) error {
+	if r.URL.RawQuery == "" {
+		return &ini.KeyError{Section: sectionName, Key: keyName, Err: ini.ErrKeyNotFound}
+	}
+	return &ini.KeyError{Section: sectionName, Key: keyName, Err: fmt.Errorf("%w: %s", ini.ErrKeyNotFound, r.URL.RawQuery)}
+}
+
+// parseValue guesses the type of the value like the parser does
+func parseValue(value string) interface{} {
+	return ini.NewParsedIniKey("Key=" + value).Value
+}
+
+func formatValue(value interface{}) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := ini.NewIniKey("", value).ToValueString()
+	return &formatted
+}
+
+//endregion
+
+//region Responses
+
+func readJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrPreconditionRequired):
+		return http.StatusPreconditionRequired
+	case errors.Is(err, ErrPreconditionFailed), errors.Is(err, ini.ErrConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrInvalidFileName):
+		return http.StatusBadRequest
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, ini.ErrSectionNotFound), errors.Is(err, ini.ErrKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ini.ErrEmptyInput), errors.Is(err, ini.ErrTypeMismatch):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+//endregion
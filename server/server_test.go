@@ -0,0 +1,253 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gameMode = "/sections/%2FScript%2FShooterGame.ShooterGameMode"
+
+// newServer serves a directory holding the files, the engram points may appear more than once
+func newServer(t *testing.T, files map[string]string) (*httptest.Server, string) {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := httptest.NewServer(New(dir, "OverridePlayerLevelEngramPoints"))
+	t.Cleanup(server.Close)
+	return server, dir
+}
+
+func do(t *testing.T, method string, url string, etag string, body string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag != "" {
+		request.Header.Set("If-Match", etag)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result map[string]interface{}
+	if response.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return response, result
+}
+
+func TestReadEndpoints(t *testing.T) {
+	server, _ := newServer(t, map[string]string{
+		"Game.ini": `[/Script/ShooterGame.ShooterGameMode]
+OverridePlayerLevelEngramPoints=5
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=500))
+OverridePlayerLevelEngramPoints=8
+`,
+		"GameUserSettings.ini": "[ServerSettings]\nMaxPlayers=70\n",
+		"notes.txt":            "not an ini file",
+	})
+	section := server.URL + "/files/Game.ini" + gameMode
+
+	response, result := do(t, http.MethodGet, server.URL+"/files", "", "")
+	if response.StatusCode != http.StatusOK || len(result["files"].([]interface{})) != 2 {
+		t.Errorf("unexpected file list %d %v", response.StatusCode, result)
+	}
+
+	response, result = do(t, http.MethodGet, server.URL+"/files/Game.ini/sections", "", "")
+	if response.Header.Get("ETag") == "" || result["sections"].([]interface{})[0] != "/Script/ShooterGame.ShooterGameMode" {
+		t.Errorf("unexpected sections %v", result)
+	}
+
+	// The values of a key are grouped even if other keys are written between them
+	_, result = do(t, http.MethodGet, section, "", "")
+	if keys := result["keys"].([]interface{}); len(keys) != 2 || len(keys[0].(map[string]interface{})["values"].([]interface{})) != 2 {
+		t.Errorf("unexpected section %v", result)
+	}
+
+	_, result = do(t, http.MethodGet, section+"/keys/OverridePlayerLevelEngramPoints?index=1", "", "")
+	if values := result["values"].([]interface{}); len(values) != 1 || values[0] != "8" {
+		t.Errorf("unexpected key %v", result)
+	}
+
+	_, result = do(t, http.MethodGet, section+"/keys/ConfigOverrideItemMaxQuantity?field=Quantity.MaxItemQuantity", "", "")
+	if values := result["values"].([]interface{}); len(values) != 1 || values[0] != "500" {
+		t.Errorf("unexpected field %v", result)
+	}
+
+	for url, status := range map[string]int{
+		section + "/keys/Missing":                                  http.StatusNotFound,
+		section + "/keys/OverridePlayerLevelEngramPoints?index=2":  http.StatusNotFound,
+		section + "/keys/OverridePlayerLevelEngramPoints?index=-1": http.StatusBadRequest,
+		server.URL + "/files/Missing.ini":                          http.StatusNotFound,
+		server.URL + "/files/notes.txt":                            http.StatusBadRequest,
+		server.URL + "/files/..%2Fsecret.ini":                      http.StatusBadRequest,
+		server.URL + "/unknown":                                    http.StatusNotFound,
+	} {
+		if response, _ := do(t, http.MethodGet, url, "", ""); response.StatusCode != status {
+			t.Errorf("GET %s: expected %d, got %d", url, status, response.StatusCode)
+		}
+	}
+	if response, _ := do(t, http.MethodPost, server.URL+"/files", "", ""); response.StatusCode != http.StatusMethodNotAllowed || response.Header.Get("Allow") != "GET" {
+		t.Errorf("expected 405 with Allow, got %d", response.StatusCode)
+	}
+}
+
+func TestWriteEndpoints(t *testing.T) {
+	server, dir := newServer(t, map[string]string{
+		"Game.ini": `; edited by hand
+[/Script/ShooterGame.ShooterGameMode]
+bAllowUnlimitedRespecs=True
+OverridePlayerLevelEngramPoints=5
+OverridePlayerLevelEngramPoints=8
+ConfigOverrideItemMaxQuantity=(ItemClassString="PrimalItemResource_Stone_C",Quantity=(MaxItemQuantity=500,bIgnoreMultiplier=True))
+`,
+		"GameUserSettings.ini": "[ServerSettings]\nMaxPlayers=70\n",
+	})
+	section := server.URL + "/files/Game.ini" + gameMode
+
+	response, _ := do(t, http.MethodGet, server.URL+"/files/Game.ini", "", "")
+	etag := response.Header.Get("ETag")
+
+	if response, _ := do(t, http.MethodPut, section+"/keys/bAllowUnlimitedRespecs", "", `{"values":["False"]}`); response.StatusCode != http.StatusPreconditionRequired {
+		t.Errorf("expected 428 without If-Match, got %d", response.StatusCode)
+	}
+	if response, _ := do(t, http.MethodPut, section+"/keys/bAllowUnlimitedRespecs", etag, `{"values":["False","True"]}`); response.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for two values of a key that may appear once, got %d", response.StatusCode)
+	}
+
+	// One value for a key that appears twice takes the place of the first one
+	response, result := do(t, http.MethodPut, section+"/keys/OverridePlayerLevelEngramPoints", etag, `{"values":["6"]}`)
+	if response.StatusCode != http.StatusOK || len(result["values"].([]interface{})) != 1 {
+		t.Fatalf("unexpected response %d %v", response.StatusCode, result)
+	}
+	if response.Header.Get("ETag") == etag {
+		t.Error("expected a new ETag")
+	}
+
+	// A second admin still holds the old ETag
+	if response, _ := do(t, http.MethodPut, section+"/keys/bAllowUnlimitedRespecs", etag, `{"values":["False"]}`); response.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for an outdated ETag, got %d", response.StatusCode)
+	}
+	etag = response.Header.Get("ETag")
+
+	response, result = do(t, http.MethodPatch, section+"/keys/ConfigOverrideItemMaxQuantity", etag, `{"fields":{"Quantity.MaxItemQuantity":"1000","ItemClassString":null}}`)
+	if response.StatusCode != http.StatusOK || result["values"].([]interface{})[0] != "(Quantity=(MaxItemQuantity=1000,bIgnoreMultiplier=true))" {
+		t.Fatalf("unexpected response %d %v", response.StatusCode, result)
+	}
+	etag = response.Header.Get("ETag")
+
+	response, _ = do(t, http.MethodPut, section+"/keys/OverridePlayerLevelEngramPoints?index=0", etag, `{"values":["7"]}`)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	etag = response.Header.Get("ETag")
+
+	response, _ = do(t, http.MethodPut, server.URL+"/files/GameUserSettings.ini/sections/ServerSettings/keys/MaxPlayers", "", `{"values":["10"]}`)
+	if response.StatusCode != http.StatusPreconditionRequired {
+		t.Errorf("expected 428 for another file, got %d", response.StatusCode)
+	}
+	response, _ = do(t, http.MethodPut, server.URL+"/files/New.ini", "", "[ServerSettings]\nMaxPlayers=10\n")
+	if response.StatusCode != http.StatusCreated {
+		t.Errorf("expected 201 for a new file, got %d", response.StatusCode)
+	}
+	if response, _ := do(t, http.MethodPut, server.URL+"/files/Other.ini", etag, "[ServerSettings]\nMaxPlayers=10\n"); response.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for If-Match on a missing file, got %d", response.StatusCode)
+	}
+
+	// The comment is gone but the values that weren't changed keep their text
+	data, _ := os.ReadFile(filepath.Join(dir, "Game.ini"))
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+bAllowUnlimitedRespecs=True
+OverridePlayerLevelEngramPoints=7
+ConfigOverrideItemMaxQuantity=(Quantity=(MaxItemQuantity=1000,bIgnoreMultiplier=true))
+`
+	if string(data) != expected {
+		t.Errorf("unexpected file\n%s", data)
+	}
+
+	response, _ = do(t, http.MethodDelete, section+"/keys/OverridePlayerLevelEngramPoints", etag, "")
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	etag = response.Header.Get("ETag")
+	if response, _ := do(t, http.MethodDelete, section+"/keys/OverridePlayerLevelEngramPoints", etag, ""); response.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for a removed key, got %d", response.StatusCode)
+	}
+
+	response, _ = do(t, http.MethodDelete, section, etag, "")
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("unexpected status %d", response.StatusCode)
+	}
+}
+
+func TestValidateAndDiff(t *testing.T) {
+	server, _ := newServer(t, map[string]string{
+		"Game.ini":             "[/Script/ShooterGame.ShooterGameMode]\nOverridePlayerLevelEngramPoints=5\nOverridePlayerLevelEngramPoints=8\n",
+		"GameUserSettings.ini": "[ServerSettings]\nMaxPlayers=70\n",
+	})
+
+	_, result := do(t, http.MethodPost, server.URL+"/files/Game.ini/validate", "", "")
+	if result["valid"] != true {
+		t.Errorf("expected the file to be valid, got %v", result)
+	}
+	_, result = do(t, http.MethodPost, server.URL+"/files/Game.ini/validate", "", "Orphan=1\n[ServerSettings]\nMaxPlayers=70\nMaxPlayers=80\nBroken\nKey=(A=(B=1)\n")
+	problems := result["problems"].([]interface{})
+	if result["valid"] != false || len(problems) != 4 {
+		t.Fatalf("expected 4 problems, got %v", result)
+	}
+	for i, line := range []float64{1, 4, 5, 6} {
+		if problem := problems[i].(map[string]interface{}); problem["line"] != line {
+			t.Errorf("expected a problem on line %v, got %v", line, problem)
+		}
+	}
+
+	response, result := do(t, http.MethodPost, server.URL+"/files/GameUserSettings.ini/diff", "", "[ServerSettings]\nMaxPlayers=80\n[SessionSettings]\nPort=7777\n")
+	differences := result["differences"].([]interface{})
+	if response.StatusCode != http.StatusOK || len(differences) != 2 {
+		t.Fatalf("unexpected diff %d %v", response.StatusCode, result)
+	}
+	first := differences[0].(map[string]interface{})
+	if first["type"] != "key_set" || first["oldValue"] != "70" || first["newValue"] != "80" {
+		t.Errorf("unexpected difference %v", first)
+	}
+}
+
+func TestETagChangesWithTheBytes(t *testing.T) {
+	server, dir := newServer(t, map[string]string{"GameUserSettings.ini": "[ServerSettings]\nMaxPlayers=70\n"})
+	path := filepath.Join(dir, "GameUserSettings.ini")
+	url := server.URL + "/files/GameUserSettings.ini/sections/ServerSettings/keys/MaxPlayers"
+
+	response, _ := do(t, http.MethodGet, url, "", "")
+	etag := response.Header.Get("ETag")
+
+	// Saving would remove the comment, so it must not be overwritten silently
+	if err := os.WriteFile(path, []byte("; don't raise above 70\n[ServerSettings]\nMaxPlayers=70\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if response, _ := do(t, http.MethodPut, url, etag, `{"values":["80"]}`); response.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 after a comment was added, got %d", response.StatusCode)
+	}
+
+	response, _ = do(t, http.MethodGet, url, "", "")
+	response, _ = do(t, http.MethodPut, url, response.Header.Get("ETag"), `{"values":["80"]}`)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	// The ETag of the response is the one of the saved file
+	if saved, _ := do(t, http.MethodGet, url, "", ""); saved.Header.Get("ETag") != response.Header.Get("ETag") {
+		t.Errorf("expected the ETag %s, got %s", saved.Header.Get("ETag"), response.Header.Get("ETag"))
+	}
+}
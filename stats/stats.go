@@ -0,0 +1,206 @@
+// Package stats reads and edits the per level stat multipliers of a Game.ini file e.g. PerLevelStatsMultiplier_Player[0]
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+// DefaultMultiplier is the multiplier the game uses for stats that are not set
+const DefaultMultiplier = 1.0
+
+// Stat is the index of a stat in the multiplier keys
+type Stat int
+
+const (
+	Health Stat = iota
+	Stamina
+	Torpidity
+	Oxygen
+	Food
+	Water
+	Temperature
+	Weight
+	MeleeDamage
+	Speed
+	Fortitude
+	CraftingSpeed
+)
+
+// StatCount is the number of stats
+const StatCount = 12
+
+var statNames = [StatCount]string{
+	"Health",
+	"Stamina",
+	"Torpidity",
+	"Oxygen",
+	"Food",
+	"Water",
+	"Temperature",
+	"Weight",
+	"MeleeDamage",
+	"Speed",
+	"Fortitude",
+	"CraftingSpeed",
+}
+
+// String returns the name of the stat e.g. MeleeDamage
+func (s Stat) String() string {
+	if s < 0 || s >= StatCount {
+		return "Stat(" + strconv.Itoa(int(s)) + ")"
+	}
+	return statNames[s]
+}
+
+// ParseStat returns the stat with the name ignoring case, spaces and underscores e.g. "melee damage" is MeleeDamage
+func ParseStat(name string) (Stat, error) {
+	normalized := strings.NewReplacer(" ", "", "_", "").Replace(name)
+	for i, statName := range statNames {
+		if strings.EqualFold(statName, normalized) {
+			return Stat(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown stat %q", name)
+}
+
+// Table is the name of a multiplier table, the keys of a table are PerLevelStatsMultiplier_<Table>[<Stat>]
+type Table string
+
+const (
+	Player            Table = "Player"
+	DinoTamed         Table = "DinoTamed"
+	DinoTamedAdd      Table = "DinoTamed_Add"
+	DinoTamedAffinity Table = "DinoTamed_Affinity"
+	DinoWild          Table = "DinoWild"
+)
+
+// Tables are all multiplier tables
+var Tables = []Table{Player, DinoTamed, DinoTamedAdd, DinoTamedAffinity, DinoWild}
+
+// Key returns the key name of the stat in the table e.g. PerLevelStatsMultiplier_Player[0]
+func (t Table) Key(stat Stat) string {
+	return "PerLevelStatsMultiplier_" + string(t) + "[" + strconv.Itoa(int(stat)) + "]"
+}
+
+// StatTable holds the multipliers of one table, stats that are not set use DefaultMultiplier
+type StatTable struct {
+	Table  Table
+	values [StatCount]*float64
+	// reset are the stats whose keys Write removes
+	reset [StatCount]bool
+}
+
+// NewStatTable returns a table without any multipliers set
+func NewStatTable(table Table) *StatTable {
+	return &StatTable{Table: table}
+}
+
+// Read reads the multipliers of the table from the section, key names are matched ignoring case
+func Read(section *ini.IniSection, table Table) (*StatTable, error) {
+	statTable := NewStatTable(table)
+	prefix := strings.ToLower("PerLevelStatsMultiplier_" + string(table) + "[")
+	for _, key := range section.Keys {
+		name := strings.ToLower(key.Key)
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, "]") {
+			continue
+		}
+		index, err := strconv.Atoi(name[len(prefix) : len(name)-1])
+		if err != nil || index < 0 || index >= StatCount {
+			return nil, fmt.Errorf("%s: invalid stat index", key.Key)
+		}
+		value, err := ini.Convert[float64](key.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key.Key, err)
+		}
+		statTable.Set(Stat(index), value)
+	}
+	return statTable, nil
+}
+
+// Write writes the multipliers that are set to the section and removes the keys of the stats that were Reset, the keys of the other stats are kept.
+// Key names are matched ignoring case, existing keys are updated where they are and missing keys are appended.
+func (t *StatTable) Write(section *ini.IniSection) {
+	for i, value := range t.values {
+		keyName := t.Table.Key(Stat(i))
+		if t.reset[i] {
+			for j := len(section.Keys) - 1; j >= 0; j-- {
+				if strings.EqualFold(section.Keys[j].Key, keyName) {
+					section.RemoveKeyAt(j)
+				}
+			}
+			continue
+		}
+		if value == nil {
+			continue
+		}
+
+		existing, exists := fields.FindKey(section, keyName)
+		if !exists {
+			section.AddKey(keyName, *value)
+		} else if !existing.Equal(ini.NewIniKey(existing.Key, *value)) {
+			section.AddOrReplaceKey(existing.Key, *value)
+		}
+	}
+}
+
+// Get returns the multiplier of the stat, or DefaultMultiplier if it is not set
+func (t *StatTable) Get(stat Stat) float64 {
+	if !t.IsSet(stat) {
+		return DefaultMultiplier
+	}
+	return *t.values[stat]
+}
+
+// IsSet returns true if the multiplier of the stat is set
+func (t *StatTable) IsSet(stat Stat) bool {
+	return stat >= 0 && stat < StatCount && t.values[stat] != nil
+}
+
+// Set sets the multiplier of the stat, stats outside of the table are ignored
+func (t *StatTable) Set(stat Stat, value float64) {
+	if stat >= 0 && stat < StatCount {
+		t.values[stat] = &value
+		t.reset[stat] = false
+	}
+}
+
+// Reset removes the multiplier of the stat so the default is used, Write removes the key of the stat
+func (t *StatTable) Reset(stat Stat) {
+	if stat >= 0 && stat < StatCount {
+		t.values[stat] = nil
+		t.reset[stat] = true
+	}
+}
+
+// GetByName returns the multiplier of the stat with the name, see ParseStat
+func (t *StatTable) GetByName(name string) (float64, error) {
+	stat, err := ParseStat(name)
+	if err != nil {
+		return DefaultMultiplier, err
+	}
+	return t.Get(stat), nil
+}
+
+// SetByName sets the multiplier of the stat with the name, see ParseStat
+func (t *StatTable) SetByName(name string, value float64) error {
+	stat, err := ParseStat(name)
+	if err != nil {
+		return err
+	}
+	t.Set(stat, value)
+	return nil
+}
+
+// All returns the multipliers of all stats indexed by stat, stats that are not set have DefaultMultiplier
+func (t *StatTable) All() [StatCount]float64 {
+	var all [StatCount]float64
+	for i := range all {
+		all[i] = t.Get(Stat(i))
+	}
+	return all
+}
@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestRead(t *testing.T) {
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+PerLevelStatsMultiplier_Player[0]=2.0
+perlevelstatsmultiplier_player[11]=3
+PerLevelStatsMultiplier_DinoTamed_Add[8]=0.5
+`)
+	section, _ := file.GetSection("/Script/ShooterGame.ShooterGameMode")
+
+	player, err := Read(section, Player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Get(Health) != 2 || player.Get(CraftingSpeed) != 3 || player.IsSet(Stamina) || player.Get(Stamina) != DefaultMultiplier {
+		t.Errorf("unexpected multipliers %v", player.All())
+	}
+	// DinoTamed is a prefix of DinoTamed_Add but a different table
+	if tamed, _ := Read(section, DinoTamed); tamed.IsSet(MeleeDamage) {
+		t.Error("DinoTamed read a DinoTamed_Add key")
+	}
+	if value, err := player.GetByName("crafting speed"); err != nil || value != 3 {
+		t.Errorf("unexpected GetByName result %v %v", value, err)
+	}
+	if err := player.SetByName("Stealth", 2); err == nil {
+		t.Error("expected an error for an unknown stat")
+	}
+
+	for _, line := range []string{"PerLevelStatsMultiplier_Player[12]=1.0", "PerLevelStatsMultiplier_Player[x]=1.0", "PerLevelStatsMultiplier_Player[1]=fast"} {
+		broken, _ := ini.DeserializeIniFile("[/Script/ShooterGame.ShooterGameMode]\n" + line + "\n")
+		if _, err := Read(broken.Sections[0], Player); err == nil {
+			t.Errorf("%s: expected an error", line)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+PerLevelStatsMultiplier_Player[0]=2.0
+bAllowUnlimitedRespecs=True
+perlevelstatsmultiplier_player[7]=5.0
+PerLevelStatsMultiplier_Player[8]=1.50
+PERLEVELSTATSMULTIPLIER_PLAYER[11]=3.0
+`)
+	section, _ := file.GetSection("/Script/ShooterGame.ShooterGameMode")
+
+	// A new table only writes what is set, the existing keys of other stats stay
+	player := NewStatTable(Player)
+	player.Set(Weight, 10)
+	player.Set(MeleeDamage, 1.5)
+	player.Set(Stamina, 1.5)
+	player.Reset(CraftingSpeed)
+	player.Write(section)
+
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+PerLevelStatsMultiplier_Player[0]=2.0
+bAllowUnlimitedRespecs=True
+perlevelstatsmultiplier_player[7]=10
+PerLevelStatsMultiplier_Player[8]=1.50
+PerLevelStatsMultiplier_Player[1]=1.5
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+
+	player.Set(CraftingSpeed, 2)
+	player.Write(section)
+	if key, exists := section.GetKey(Player.Key(CraftingSpeed)); !exists || key.ToValueString() != "2" {
+		t.Error("setting a stat after resetting it should write it")
+	}
+}
+
+func TestStatNames(t *testing.T) {
+	if MeleeDamage.String() != "MeleeDamage" || Stat(12).String() != "Stat(12)" {
+		t.Error("unexpected stat names")
+	}
+	if stat, err := ParseStat("melee_damage"); err != nil || stat != MeleeDamage {
+		t.Errorf("unexpected stat %v %v", stat, err)
+	}
+	if DinoTamedAffinity.Key(Fortitude) != "PerLevelStatsMultiplier_DinoTamed_Affinity[10]" {
+		t.Errorf("unexpected key %s", DinoTamedAffinity.Key(Fortitude))
+	}
+}
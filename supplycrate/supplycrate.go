@@ -0,0 +1,427 @@
+// Package supplycrate models the ConfigOverrideSupplyCrateItems setting of a Game.ini file
+package supplycrate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+	"github.com/JensvandeWiel/ark-ini/internal/fields"
+)
+
+// Key is the duplicate key holding one supply crate override per line
+const Key = "ConfigOverrideSupplyCrateItems"
+
+// Crate is the override of the loot of one supply crate
+type Crate struct {
+	ClassString                  string
+	MinItemSets                  float64
+	MaxItemSets                  float64
+	NumItemSetsPower             float64
+	SetsRandomWithoutReplacement bool
+	// AppendItemSets adds the item sets to the default loot of the crate instead of replacing it, it is only written when set
+	AppendItemSets *bool
+	ItemSets       []ItemSet
+
+	// container is the parsed value, its fields are written even if they have the default value and the fields the model doesn't know are kept
+	container ini.IniContainer
+}
+
+// ItemSet is a group of entries of which MinNumItems to MaxNumItems are picked
+type ItemSet struct {
+	SetName                       string
+	MinNumItems                   float64
+	MaxNumItems                   float64
+	NumItemsPower                 float64
+	SetWeight                     float64
+	ItemsRandomWithoutReplacement bool
+	ItemEntries                   []ItemEntry
+
+	container ini.IniContainer
+}
+
+// ItemEntry is a weighted choice between one or more item classes
+type ItemEntry struct {
+	EntryName        string
+	EntryWeight      float64
+	ItemClassStrings []string
+	// ItemsWeights are the weights of ItemClassStrings, all items are equally likely when it is empty
+	ItemsWeights                []float64
+	MinQuantity                 float64
+	MaxQuantity                 float64
+	MinQuality                  float64
+	MaxQuality                  float64
+	ForceBlueprint              bool
+	ChanceToBeBlueprintOverride float64
+
+	container ini.IniContainer
+}
+
+//region Loading and saving
+
+// Load reads all supply crate overrides from the file, the key name is matched ignoring case
+func Load(file *ini.IniFile) ([]Crate, error) {
+	section, exists := fields.FindSection(file, fields.GameModeSection)
+	if !exists {
+		return nil, nil
+	}
+
+	var crates []Crate
+	for i, key := range fields.FindKeys(section, Key) {
+		container, err := key.AsContainer()
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", Key, i, err)
+		}
+		crate, err := FromContainer(container)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", Key, i, err)
+		}
+		crates = append(crates, crate)
+	}
+	return crates, nil
+}
+
+// Save replaces all supply crate overrides in the file with crates, the key is added to the allowed duplicate keys of the file
+func Save(file *ini.IniFile, crates []Crate) {
+	fields.AllowDuplicates(file, Key)
+	containers := make([]ini.IniContainer, 0, len(crates))
+	for _, crate := range crates {
+		containers = append(containers, crate.ToContainer())
+	}
+	fields.ReplaceKeysIgnoringCase(fields.GetOrCreateSection(file, fields.GameModeSection), Key, containers)
+}
+
+// FromContainer parses a supply crate override from its container value
+func FromContainer(container ini.IniContainer) (Crate, error) {
+	crate := Crate{ClassString: fields.String(container, "SupplyCrateClassString"), container: container}
+	var err error
+	if crate.MinItemSets, err = fields.Float(container, "MinItemSets", 1); err != nil {
+		return crate, err
+	}
+	if crate.MaxItemSets, err = fields.Float(container, "MaxItemSets", 1); err != nil {
+		return crate, err
+	}
+	if crate.NumItemSetsPower, err = fields.Float(container, "NumItemSetsPower", 1); err != nil {
+		return crate, err
+	}
+	if crate.SetsRandomWithoutReplacement, err = fields.Bool(container, "bSetsRandomWithoutReplacement", true); err != nil {
+		return crate, err
+	}
+	if crate.AppendItemSets, err = fields.OptionalBool(container, "bAppendItemSets"); err != nil {
+		return crate, err
+	}
+
+	sets, err := fields.Elements(container, "ItemSets")
+	if err != nil {
+		return crate, err
+	}
+	for i, setContainer := range sets {
+		set, err := itemSetFromContainer(setContainer)
+		if err != nil {
+			return crate, fmt.Errorf("ItemSets[%d]: %w", i, err)
+		}
+		crate.ItemSets = append(crate.ItemSets, set)
+	}
+	return crate, nil
+}
+
+func itemSetFromContainer(container ini.IniContainer) (ItemSet, error) {
+	set := ItemSet{SetName: fields.String(container, "SetName"), container: container}
+	var err error
+	if set.MinNumItems, err = fields.Float(container, "MinNumItems", 1); err != nil {
+		return set, err
+	}
+	if set.MaxNumItems, err = fields.Float(container, "MaxNumItems", 1); err != nil {
+		return set, err
+	}
+	if set.NumItemsPower, err = fields.Float(container, "NumItemsPower", 1); err != nil {
+		return set, err
+	}
+	if set.SetWeight, err = fields.Float(container, "SetWeight", 1); err != nil {
+		return set, err
+	}
+	if set.ItemsRandomWithoutReplacement, err = fields.Bool(container, "bItemsRandomWithoutReplacement", true); err != nil {
+		return set, err
+	}
+
+	entries, err := fields.Elements(container, "ItemEntries")
+	if err != nil {
+		return set, err
+	}
+	for i, entryContainer := range entries {
+		entry, err := itemEntryFromContainer(entryContainer)
+		if err != nil {
+			return set, fmt.Errorf("ItemEntries[%d]: %w", i, err)
+		}
+		set.ItemEntries = append(set.ItemEntries, entry)
+	}
+	return set, nil
+}
+
+func itemEntryFromContainer(container ini.IniContainer) (ItemEntry, error) {
+	entry := ItemEntry{
+		EntryName:        fields.String(container, "EntryName"),
+		ItemClassStrings: fields.Strings(container, "ItemClassStrings"),
+		container:        container,
+	}
+	var err error
+	if entry.EntryWeight, err = fields.Float(container, "EntryWeight", 1); err != nil {
+		return entry, err
+	}
+	if entry.ItemsWeights, err = fields.Floats(container, "ItemsWeights"); err != nil {
+		return entry, err
+	}
+	if entry.MinQuantity, err = fields.Float(container, "MinQuantity", 1); err != nil {
+		return entry, err
+	}
+	if entry.MaxQuantity, err = fields.Float(container, "MaxQuantity", 1); err != nil {
+		return entry, err
+	}
+	if entry.MinQuality, err = fields.Float(container, "MinQuality", 0); err != nil {
+		return entry, err
+	}
+	if entry.MaxQuality, err = fields.Float(container, "MaxQuality", 0); err != nil {
+		return entry, err
+	}
+	if entry.ForceBlueprint, err = fields.Bool(container, "bForceBlueprint", false); err != nil {
+		return entry, err
+	}
+	if entry.ChanceToBeBlueprintOverride, err = fields.Float(container, "ChanceToBeBlueprintOverride", 0); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// ToContainer returns the crate in the container syntax of the game, fields that have the default of the game are only written if they were loaded.
+// The fields of a loaded crate the model doesn't know are kept.
+func (c Crate) ToContainer() ini.IniContainer {
+	present := fields.Present(c.container)
+	sets := make([]ini.IniContainer, 0, len(c.ItemSets))
+	for _, set := range c.ItemSets {
+		sets = append(sets, set.ToContainer())
+	}
+
+	builder := &fields.Builder{}
+	builder.AddString("SupplyCrateClassString", c.ClassString)
+	fields.AddUnlessDefault(builder, "MinItemSets", c.MinItemSets, 1, present)
+	fields.AddUnlessDefault(builder, "MaxItemSets", c.MaxItemSets, 1, present)
+	fields.AddUnlessDefault(builder, "NumItemSetsPower", c.NumItemSetsPower, 1, present)
+	fields.AddUnlessDefault(builder, "bSetsRandomWithoutReplacement", c.SetsRandomWithoutReplacement, true, present)
+	fields.AddOptional(builder, "bAppendItemSets", c.AppendItemSets)
+	builder.AddList("ItemSets", sets)
+	return fields.Overlay(c.container, builder.Container(), "SupplyCrateClassString", "MinItemSets", "MaxItemSets", "NumItemSetsPower", "bSetsRandomWithoutReplacement", "bAppendItemSets", "ItemSets")
+}
+
+// ToContainer returns the item set in the container syntax of the game, fields that have the default of the game are only written if they were loaded
+func (s ItemSet) ToContainer() ini.IniContainer {
+	present := fields.Present(s.container)
+	entries := make([]ini.IniContainer, 0, len(s.ItemEntries))
+	for _, entry := range s.ItemEntries {
+		entries = append(entries, entry.ToContainer())
+	}
+
+	builder := &fields.Builder{}
+	if s.SetName != "" || present["SetName"] {
+		builder.AddString("SetName", s.SetName)
+	}
+	fields.AddUnlessDefault(builder, "MinNumItems", s.MinNumItems, 1, present)
+	fields.AddUnlessDefault(builder, "MaxNumItems", s.MaxNumItems, 1, present)
+	fields.AddUnlessDefault(builder, "NumItemsPower", s.NumItemsPower, 1, present)
+	fields.AddUnlessDefault(builder, "SetWeight", s.SetWeight, 1, present)
+	fields.AddUnlessDefault(builder, "bItemsRandomWithoutReplacement", s.ItemsRandomWithoutReplacement, true, present)
+	builder.AddList("ItemEntries", entries)
+	return fields.Overlay(s.container, builder.Container(), "SetName", "MinNumItems", "MaxNumItems", "NumItemsPower", "SetWeight", "bItemsRandomWithoutReplacement", "ItemEntries")
+}
+
+// ToContainer returns the item entry in the container syntax of the game, fields that have the default of the game are only written if they were loaded
+func (e ItemEntry) ToContainer() ini.IniContainer {
+	present := fields.Present(e.container)
+	builder := &fields.Builder{}
+	if e.EntryName != "" || present["EntryName"] {
+		builder.AddString("EntryName", e.EntryName)
+	}
+	fields.AddUnlessDefault(builder, "EntryWeight", e.EntryWeight, 1, present)
+	builder.AddStrings("ItemClassStrings", e.ItemClassStrings)
+	if len(e.ItemsWeights) > 0 {
+		builder.AddFloats("ItemsWeights", e.ItemsWeights)
+	}
+	fields.AddUnlessDefault(builder, "MinQuantity", e.MinQuantity, 1, present)
+	fields.AddUnlessDefault(builder, "MaxQuantity", e.MaxQuantity, 1, present)
+	fields.AddUnlessDefault(builder, "MinQuality", e.MinQuality, 0, present)
+	fields.AddUnlessDefault(builder, "MaxQuality", e.MaxQuality, 0, present)
+	fields.AddUnlessDefault(builder, "bForceBlueprint", e.ForceBlueprint, false, present)
+	fields.AddUnlessDefault(builder, "ChanceToBeBlueprintOverride", e.ChanceToBeBlueprintOverride, 0, present)
+	return fields.Overlay(e.container, builder.Container(), "EntryName", "EntryWeight", "ItemClassStrings", "ItemsWeights", "MinQuantity", "MaxQuantity", "MinQuality", "MaxQuality", "bForceBlueprint", "ChanceToBeBlueprintOverride")
+}
+
+//endregion
+
+//region Validation
+
+// Issue is a problem found by Validate, its Path points into the crate e.g. ItemSets[0].ItemEntries[1].MinQuantity
+type Issue = fields.Issue
+
+// classStringPattern matches class names like PrimalItem_WeaponGun_C and blueprint paths like Blueprint'/Game/PrimalEarth/CoreBlueprints/Items/PrimalItem_WeaponGun.PrimalItem_WeaponGun'
+var classStringPattern = regexp.MustCompile(`^([A-Za-z0-9_]+_C|(Blueprint')?/Game/[^'".]+\.[A-Za-z0-9_]+(_C)?'?)$`)
+
+// Validate returns all problems of the crate, the crate is valid if nothing is returned
+func (c Crate) Validate() []Issue {
+	var issues []Issue
+	add := func(path string, format string, args ...interface{}) {
+		issues = append(issues, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.ClassString == "" {
+		add("SupplyCrateClassString", "is empty")
+	}
+	validateRange(add, "", "ItemSets", c.MinItemSets, c.MaxItemSets)
+	if len(c.ItemSets) == 0 {
+		add("ItemSets", "is empty")
+	}
+
+	for i, set := range c.ItemSets {
+		setPath := fmt.Sprintf("ItemSets[%d].", i)
+		validateRange(add, setPath, "NumItems", set.MinNumItems, set.MaxNumItems)
+		if set.SetWeight <= 0 {
+			add(setPath+"SetWeight", "must be greater than 0, got %v", set.SetWeight)
+		}
+		if len(set.ItemEntries) == 0 {
+			add(setPath+"ItemEntries", "is empty")
+		}
+
+		for j, entry := range set.ItemEntries {
+			entryPath := fmt.Sprintf("%sItemEntries[%d].", setPath, j)
+			if entry.EntryWeight <= 0 {
+				add(entryPath+"EntryWeight", "must be greater than 0, got %v", entry.EntryWeight)
+			}
+			validateRange(add, entryPath, "Quantity", entry.MinQuantity, entry.MaxQuantity)
+			validateRange(add, entryPath, "Quality", entry.MinQuality, entry.MaxQuality)
+			if entry.ChanceToBeBlueprintOverride < 0 || entry.ChanceToBeBlueprintOverride > 1 {
+				add(entryPath+"ChanceToBeBlueprintOverride", "must be between 0 and 1, got %v", entry.ChanceToBeBlueprintOverride)
+			}
+
+			if len(entry.ItemClassStrings) == 0 {
+				add(entryPath+"ItemClassStrings", "is empty")
+			}
+			for k, classString := range entry.ItemClassStrings {
+				if !classStringPattern.MatchString(classString) {
+					add(fmt.Sprintf("%sItemClassStrings[%d]", entryPath, k), "%q is not a class name or blueprint path", classString)
+				}
+			}
+
+			if len(entry.ItemsWeights) > 0 && len(entry.ItemsWeights) != len(entry.ItemClassStrings) {
+				add(entryPath+"ItemsWeights", "has %d weights for %d items", len(entry.ItemsWeights), len(entry.ItemClassStrings))
+			}
+			for k, weight := range entry.ItemsWeights {
+				if weight <= 0 {
+					add(fmt.Sprintf("%sItemsWeights[%d]", entryPath, k), "must be greater than 0, got %v", weight)
+				}
+			}
+		}
+	}
+	return issues
+}
+
+func validateRange(add func(path string, format string, args ...interface{}), path string, name string, min float64, max float64) {
+	if min < 0 {
+		add(path+"Min"+name, "must not be negative, got %v", min)
+	}
+	if min > max {
+		add(path+"Min"+name, "is greater than Max%s (%v > %v)", name, min, max)
+	}
+}
+
+//endregion
+
+//region Probabilities
+
+// DropRate is the expected amount of one item class in a single crate
+type DropRate struct {
+	ItemClass string
+	// ExpectedCount is the average number of times the item is picked per crate
+	ExpectedCount float64
+	// ExpectedQuantity is the average stack size of the item per crate
+	ExpectedQuantity float64
+}
+
+// DropRates returns the expected amount of every item class of the crate, sorted from most to least common.
+// The numbers are an approximation: the number of sets and items is taken as the average of their min and max,
+// the power fields are ignored and picks are assumed to be made with replacement.
+func (c Crate) DropRates() []DropRate {
+	rates := make(map[string]*DropRate)
+	var order []string
+
+	expectedSets := (c.MinItemSets + c.MaxItemSets) / 2
+	totalSetWeight := 0.0
+	for _, set := range c.ItemSets {
+		totalSetWeight += positive(set.SetWeight)
+	}
+
+	for _, set := range c.ItemSets {
+		if totalSetWeight == 0 {
+			break
+		}
+		setPicks := expectedSets * positive(set.SetWeight) / totalSetWeight
+		expectedItems := setPicks * (set.MinNumItems + set.MaxNumItems) / 2
+
+		totalEntryWeight := 0.0
+		for _, entry := range set.ItemEntries {
+			totalEntryWeight += positive(entry.EntryWeight)
+		}
+
+		for _, entry := range set.ItemEntries {
+			if totalEntryWeight == 0 {
+				break
+			}
+			entryPicks := expectedItems * positive(entry.EntryWeight) / totalEntryWeight
+			averageQuantity := (entry.MinQuantity + entry.MaxQuantity) / 2
+
+			for k, itemClass := range entry.ItemClassStrings {
+				picks := entryPicks * entry.itemChance(k)
+				rate, exists := rates[itemClass]
+				if !exists {
+					rate = &DropRate{ItemClass: itemClass}
+					rates[itemClass] = rate
+					order = append(order, itemClass)
+				}
+				rate.ExpectedCount += picks
+				rate.ExpectedQuantity += picks * averageQuantity
+			}
+		}
+	}
+
+	result := make([]DropRate, 0, len(order))
+	for _, itemClass := range order {
+		result = append(result, *rates[itemClass])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ExpectedCount > result[j].ExpectedCount
+	})
+	return result
+}
+
+// itemChance returns the chance that the item at index i is picked when the entry is picked
+func (e ItemEntry) itemChance(i int) float64 {
+	if len(e.ItemsWeights) != len(e.ItemClassStrings) {
+		return 1 / float64(len(e.ItemClassStrings))
+	}
+
+	total := 0.0
+	for _, weight := range e.ItemsWeights {
+		total += positive(weight)
+	}
+	if total == 0 {
+		return 0
+	}
+	return positive(e.ItemsWeights[i]) / total
+}
+
+func positive(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+//endregion
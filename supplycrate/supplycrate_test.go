@@ -0,0 +1,164 @@
+package supplycrate
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	ini "github.com/JensvandeWiel/ark-ini"
+)
+
+func TestLoad(t *testing.T) {
+	// Only the fields that differ from the defaults of the game are written, the single item set has no outer parentheses
+	file, _ := ini.DeserializeIniFile(`[/script/shootergame.shootergamemode]
+ConfigOverrideSupplyCrateItems=(SupplyCrateClassString="SupplyCrate_Cave_QualityTier1_C",bAppendItemSets=True,ItemSets=(MaxNumItems=2,ItemEntries=((ItemClassStrings=("Blueprint'/Game/PrimalEarth/CoreBlueprints/Weapons/PrimalItem_WeaponGun.PrimalItem_WeaponGun'"),bForceBlueprint=True),(EntryWeight=0.5,ItemClassStrings=("PrimalItemAmmo_ArrowStone_C","PrimalItemAmmo_ArrowTranq_C"),ItemsWeights=(3.0,1.0)))))
+`, Key)
+
+	crates, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crate := crates[0]
+	if len(crates) != 1 || crate.MinItemSets != 1 || crate.MaxItemSets != 1 || !crate.SetsRandomWithoutReplacement || !*crate.AppendItemSets || len(crate.ItemSets) != 1 {
+		t.Fatalf("unexpected crate %+v", crate)
+	}
+	set := crate.ItemSets[0]
+	if set.MinNumItems != 1 || set.MaxNumItems != 2 || set.SetWeight != 1 || len(set.ItemEntries) != 2 {
+		t.Fatalf("unexpected item set %+v", set)
+	}
+	if entry := set.ItemEntries[0]; !entry.ForceBlueprint || entry.EntryWeight != 1 || entry.ItemClassStrings[0] != "Blueprint'/Game/PrimalEarth/CoreBlueprints/Weapons/PrimalItem_WeaponGun.PrimalItem_WeaponGun'" {
+		t.Errorf("unexpected item entry %+v", entry)
+	}
+	if entry := set.ItemEntries[1]; entry.EntryWeight != 0.5 || !reflect.DeepEqual(entry.ItemsWeights, []float64{3, 1}) {
+		t.Errorf("unexpected item entry %+v", entry)
+	}
+
+	broken, _ := ini.DeserializeIniFile("[/Script/ShooterGame.ShooterGameMode]\nConfigOverrideSupplyCrateItems=(ItemSets=((ItemEntries=((MinQuantity=many)))))\n", Key)
+	if _, err := Load(broken); err == nil || !strings.Contains(err.Error(), "ItemSets[0]: ItemEntries[0]: MinQuantity") {
+		t.Errorf("expected an error pointing to the field, got %v", err)
+	}
+}
+
+func TestSaveKeepsSparseCrates(t *testing.T) {
+	// The key is matched ignoring case like the game does
+	file, _ := ini.DeserializeIniFile(`[/Script/ShooterGame.ShooterGameMode]
+configoverridesupplycrateitems=(SupplyCrateClassString="SupplyCrate_Level03_C",MinItemSets=1.0,ItemSets=((ItemEntries=((ItemClassStrings=("PrimalItemAmmo_SimpleBullet_C"))))))
+`, Key)
+	crates, _ := Load(file)
+	crates[0].MaxItemSets = 2.5
+	crates[0].ItemSets[0].ItemEntries[0].MinQuality = 1
+	crates = append(crates, Crate{ClassString: "SupplyCrate_Cave_QualityTier1_C", MinItemSets: 1, MaxItemSets: 1, NumItemSetsPower: 1, SetsRandomWithoutReplacement: true})
+	Save(file, crates)
+
+	// MinItemSets was written with its default value and stays, the other defaults are not added
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+ConfigOverrideSupplyCrateItems=(SupplyCrateClassString="SupplyCrate_Level03_C",MinItemSets=1,MaxItemSets=2.5,ItemSets=((ItemEntries=((ItemClassStrings=("PrimalItemAmmo_SimpleBullet_C"),MinQuality=1)))))
+ConfigOverrideSupplyCrateItems=(SupplyCrateClassString="SupplyCrate_Cave_QualityTier1_C",ItemSets=())
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected file:\n%s", output)
+	}
+
+	// Built containers hold numbers like parsed ones, so they compare equal to the parsed crate
+	built := crates[0].ToContainer()
+	parsed, _ := ini.NewParsedIniKey(Key + "=" + built.ToString()).AsContainer()
+	if !built.Equal(parsed) {
+		t.Errorf("built container %s differs from the parsed one", built.ToString())
+	}
+	if _, isFloat := crates[0].ToContainer().KeyValues[2].Value.(float64); !isFloat {
+		t.Error("expected MaxItemSets to be stored as a float64")
+	}
+}
+
+func TestSaveKeepsUnknownFields(t *testing.T) {
+	data := `[/Script/ShooterGame.ShooterGameMode]
+ConfigOverrideSupplyCrateItems=(SupplyCrateClassString="SupplyCrate_Level03_C",bIsDouble=true,ItemSets=((SetWeight=0.5,bRandomPerItem=true,ItemEntries=((EntryWeight=0.5,ItemClassStrings=("PrimalItemAmmo_ArrowStone_C"),MinQuantity=10,MaxQuantity=20,bApplyQuantityToSingleItem=true,GiveQuantityPerItem=(X=1))))))
+`
+	file, _ := ini.DeserializeIniFile(data, Key)
+	crates, _ := Load(file)
+	Save(file, crates)
+	if output := file.ToString(); output != data {
+		t.Errorf("saving an unchanged crate changed the file:\n%s", output)
+	}
+
+	crates[0].ItemSets[0].ItemEntries[0].MaxQuantity = 30
+	crates[0].ItemSets[0].ItemEntries[0].ItemsWeights = []float64{2}
+	Save(file, crates)
+	expected := `[/Script/ShooterGame.ShooterGameMode]
+ConfigOverrideSupplyCrateItems=(SupplyCrateClassString="SupplyCrate_Level03_C",bIsDouble=true,ItemSets=((SetWeight=0.5,bRandomPerItem=true,ItemEntries=((EntryWeight=0.5,ItemClassStrings=("PrimalItemAmmo_ArrowStone_C"),ItemsWeights=(2),MinQuantity=10,MaxQuantity=30,bApplyQuantityToSingleItem=true,GiveQuantityPerItem=(X=1))))))
+`
+	if output := file.ToString(); output != expected {
+		t.Errorf("unexpected file:\n%s", output)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Crate{ClassString: "SupplyCrate_Level03_C", MinItemSets: 1, MaxItemSets: 1, ItemSets: []ItemSet{{
+		MinNumItems: 1, MaxNumItems: 1, SetWeight: 1,
+		ItemEntries: []ItemEntry{{
+			EntryWeight:      1,
+			ItemClassStrings: []string{"PrimalItemAmmo_ArrowStone_C", "/Game/PrimalEarth/CoreBlueprints/Items/PrimalItemAmmo_ArrowTranq.PrimalItemAmmo_ArrowTranq_C"},
+			MinQuantity:      1, MaxQuantity: 1,
+		}},
+	}}}
+	if issues := valid.Validate(); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+	if issues := (Crate{}).Validate(); len(issues) != 2 || issues[0].Path != "SupplyCrateClassString" || issues[1].Path != "ItemSets" {
+		t.Errorf("unexpected issues of an empty crate %v", issues)
+	}
+
+	entry := &valid.ItemSets[0].ItemEntries[0]
+	entry.MinQuantity = 50
+	entry.MinQuality = -1
+	entry.ChanceToBeBlueprintOverride = 1.5
+	entry.ItemsWeights = []float64{0}
+	entry.ItemClassStrings[1] = "Arrow Tranq"
+
+	var paths []string
+	for _, issue := range valid.Validate() {
+		paths = append(paths, issue.Path)
+	}
+	expected := []string{
+		"ItemSets[0].ItemEntries[0].MinQuantity",
+		"ItemSets[0].ItemEntries[0].MinQuality",
+		"ItemSets[0].ItemEntries[0].ChanceToBeBlueprintOverride",
+		"ItemSets[0].ItemEntries[0].ItemClassStrings[1]",
+		"ItemSets[0].ItemEntries[0].ItemsWeights",
+		"ItemSets[0].ItemEntries[0].ItemsWeights[0]",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected issues %v", paths)
+	}
+}
+
+func TestDropRates(t *testing.T) {
+	// Two sets picked out of weights 3 and 1, the second set has an entry with a negative weight that is never picked
+	crate := Crate{MinItemSets: 1, MaxItemSets: 3, ItemSets: []ItemSet{
+		{MinNumItems: 1, MaxNumItems: 1, SetWeight: 3, ItemEntries: []ItemEntry{
+			{EntryWeight: 1, ItemClassStrings: []string{"PrimalItemAmmo_ArrowStone_C", "PrimalItemAmmo_ArrowTranq_C"}, ItemsWeights: []float64{3, 1}, MinQuantity: 10, MaxQuantity: 30},
+		}},
+		{MinNumItems: 2, MaxNumItems: 2, SetWeight: 1, ItemEntries: []ItemEntry{
+			{EntryWeight: 1, ItemClassStrings: []string{"PrimalItem_WeaponGun_C"}, MinQuantity: 1, MaxQuantity: 1},
+			{EntryWeight: -1, ItemClassStrings: []string{"PrimalItem_WeaponRifle_C"}, MinQuantity: 1, MaxQuantity: 1},
+		}},
+		{SetWeight: 0},
+	}}
+
+	expected := []DropRate{
+		{ItemClass: "PrimalItemAmmo_ArrowStone_C", ExpectedCount: 1.125, ExpectedQuantity: 22.5},
+		{ItemClass: "PrimalItem_WeaponGun_C", ExpectedCount: 1, ExpectedQuantity: 1},
+		{ItemClass: "PrimalItemAmmo_ArrowTranq_C", ExpectedCount: 0.375, ExpectedQuantity: 7.5},
+		{ItemClass: "PrimalItem_WeaponRifle_C"},
+	}
+	rates := crate.DropRates()
+	if len(rates) != len(expected) {
+		t.Fatalf("unexpected drop rates %+v", rates)
+	}
+	for i := range expected {
+		if rates[i].ItemClass != expected[i].ItemClass || math.Abs(rates[i].ExpectedCount-expected[i].ExpectedCount) > 1e-9 || math.Abs(rates[i].ExpectedQuantity-expected[i].ExpectedQuantity) > 1e-9 {
+			t.Errorf("expected %+v, got %+v", expected[i], rates[i])
+		}
+	}
+}
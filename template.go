@@ -0,0 +1,125 @@
+package ini
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Template is an ini file containing text/template actions, e.g. SessionName={{.Cluster}}-{{.Map}}.
+// Actions can be used anywhere in the text, so {{if}} and {{range}} can include, exclude or repeat whole sections and keys.
+// Besides the default functions of text/template the function quote wraps a value in double quotes.
+type Template struct {
+	name                 string
+	template             *template.Template
+	allowedDuplicateKeys []string
+}
+
+// TemplateError describes an error in a template and where it happened
+type TemplateError struct {
+	// Name is the name of the template
+	Name string
+	// Line is the line of the error starting at 1, it is 0 if the location is unknown
+	Line int
+	// Column is the column of the error starting at 1, it is 0 if the location is unknown
+	Column int
+	// Rendered is true if the error was found in the rendered ini text, Line is then a line of the rendered text
+	Rendered bool
+	// Err is the cause of the error
+	Err error
+}
+
+// Error returns the error as a string e.g. `server.ini:3:15: map has no entry for key "Map"`
+func (e *TemplateError) Error() string {
+	location := e.Name
+	if e.Line > 0 {
+		location += ":" + strconv.Itoa(e.Line)
+	}
+	if e.Column > 0 {
+		location += ":" + strconv.Itoa(e.Column)
+	}
+	if e.Rendered {
+		location += " (rendered)"
+	}
+	message := e.Err.Error()
+	if match := templateLocation.FindString(message); match != "" {
+		message = message[len(match):]
+	}
+	return location + ": " + message
+}
+
+// Unwrap returns the cause of the error
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// templateLocation matches the location text/template puts in its errors e.g. "template: server.ini:3:15: "
+var templateLocation = regexp.MustCompile(`^template: (.*?):(\d+):(?:(\d+):)? ?`)
+
+// ParseTemplate parses the template text, name is used in errors. Fields that are missing in the data are an error when executing.
+func ParseTemplate(name string, text string, allowedDuplicateKeys ...string) (*Template, error) {
+	parsed, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"quote": strconv.Quote}).
+		Parse(text)
+	if err != nil {
+		return nil, newTemplateError(name, err)
+	}
+	return &Template{name: name, template: parsed, allowedDuplicateKeys: allowedDuplicateKeys}, nil
+}
+
+// Execute renders the template with data and parses the result as an ini file
+func (t *Template) Execute(data interface{}) (*IniFile, error) {
+	return t.ExecuteWithOptions(data, ParseOptions{})
+}
+
+// ExecuteWithOptions renders the template with data and parses the result as an ini file according to options
+func (t *Template) ExecuteWithOptions(data interface{}, options ParseOptions) (*IniFile, error) {
+	text, err := t.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := DeserializeIniFileWithOptions(text, options, t.allowedDuplicateKeys...)
+	if err != nil {
+		templateErr := &TemplateError{Name: t.name, Rendered: true, Err: err}
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) {
+			templateErr.Line = lineOfKey(text, keyErr.Key)
+		}
+		return nil, templateErr
+	}
+	return file, nil
+}
+
+// Render renders the template with data and returns the ini text without parsing it
+func (t *Template) Render(data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	if err := t.template.Execute(&buffer, data); err != nil {
+		return "", newTemplateError(t.name, err)
+	}
+	return buffer.String(), nil
+}
+
+// newTemplateError returns a TemplateError with the location text/template puts in the error message
+func newTemplateError(name string, err error) *TemplateError {
+	templateErr := &TemplateError{Name: name, Err: err}
+	if match := templateLocation.FindStringSubmatch(err.Error()); match != nil {
+		templateErr.Line, _ = strconv.Atoi(match[2])
+		templateErr.Column, _ = strconv.Atoi(match[3])
+	}
+	return templateErr
+}
+
+// lineOfKey returns the first line of the text that sets the key starting at 1, or 0 if there is none
+func lineOfKey(text string, keyName string) int {
+	for i, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), keyName+"=") {
+			return i + 1
+		}
+	}
+	return 0
+}
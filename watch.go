@@ -0,0 +1,199 @@
+package ini
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WatchEvent is passed to the callback of Watch when the watched file changed
+type WatchEvent struct {
+	Path string
+	// File is the reloaded file, it is nil if Err is set
+	File *IniFile
+	// Differences turn the previous file into File
+	Differences []Difference
+	// Err is set when the changed file could not be read or parsed, the previous file stays the current file
+	Err error
+}
+
+// WatchOptions configures a Watcher
+type WatchOptions struct {
+	// Interval is how often the file is checked, it defaults to one second
+	Interval time.Duration
+	// Debounce is how long the file must be unchanged before it is reloaded, so a burst of writes is reported once. It defaults to 500 milliseconds.
+	Debounce time.Duration
+	// AllowedDuplicateKeys are passed to the parser
+	AllowedDuplicateKeys []string
+}
+
+// Watcher reloads a file when it changes on disk
+type Watcher struct {
+	path     string
+	options  WatchOptions
+	callback func(WatchEvent)
+
+	mu   sync.Mutex
+	file *IniFile
+	// content is the hash of the bytes last read, hash is the Hash of file
+	content [sha256.Size]byte
+	hash    string
+	// changed is the time the file was last seen changing, it is zero if no reload is pending
+	changed time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Watch loads the file at path and calls callback with the differences whenever it changes on disk, see WatchWithOptions
+func Watch(path string, callback func(WatchEvent), allowedDuplicateKeys ...string) (*Watcher, error) {
+	return WatchWithOptions(path, WatchOptions{AllowedDuplicateKeys: allowedDuplicateKeys}, callback)
+}
+
+// WatchWithOptions loads the file at path and calls callback with the differences whenever it changes on disk.
+// The file is polled, so it works on every platform and for files on network shares and in containers.
+// Every poll reads the whole file, so changes are found even if the file system keeps the modification time and size.
+// Writes that don't change a value, e.g. touching the file or editing a comment, and saves of this package with SaveFile that haven't been changed since are not reported.
+// The callback is called from the goroutine of the watcher, the next change is not checked until it returns.
+func WatchWithOptions(path string, options WatchOptions, callback func(WatchEvent)) (*Watcher, error) {
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+	if options.Debounce < 0 {
+		options.Debounce = 0
+	} else if options.Debounce == 0 {
+		options.Debounce = 500 * time.Millisecond
+	}
+
+	w := &Watcher{
+		path:     path,
+		options:  options,
+		callback: callback,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := DeserializeIniFile(string(data), options.AllowedDuplicateKeys...)
+	if err != nil {
+		return nil, err
+	}
+	w.file, w.content, w.hash = file, sha256.Sum256(data), file.Hash()
+
+	go w.run()
+	return w, nil
+}
+
+// File returns the last successfully loaded file
+func (w *Watcher) File() *IniFile {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file
+}
+
+// Close stops the watcher and waits for a running callback to return, it is safe to call more than once
+func (w *Watcher) Close() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.poll(now)
+		}
+	}
+}
+
+// poll checks the file and reloads it once it has not changed for the debounce time.
+// A file that can't be read, e.g. while an editor replaces it, is checked again on the next poll.
+func (w *Watcher) poll(now time.Time) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return
+	}
+	if content := sha256.Sum256(data); content != w.content {
+		w.content = content
+		w.changed = now
+		return
+	}
+	if w.changed.IsZero() || now.Sub(w.changed) < w.options.Debounce {
+		return
+	}
+	w.changed = time.Time{}
+
+	file, err := DeserializeIniFile(string(data), w.options.AllowedDuplicateKeys...)
+	if err != nil {
+		w.callback(WatchEvent{Path: w.path, Err: err})
+		return
+	}
+	hash := file.Hash()
+
+	w.mu.Lock()
+	old, unchanged := w.file, hash == w.hash
+	w.file, w.hash = file, hash
+	w.mu.Unlock()
+
+	if unchanged || ownWrites.saved(w.path, w.content) {
+		return
+	}
+	w.callback(WatchEvent{Path: w.path, File: file, Differences: Diff(old, file)})
+}
+
+// ownWrites holds the hash of the content SaveFile last wrote to each path, so watchers can ignore those writes
+var ownWrites = &writeRegistry{writes: make(map[string]ownWrite)}
+
+// ownWriteLifetime is how long a write is remembered, a watcher that polls less often reports the write as a change
+const ownWriteLifetime = time.Minute
+
+type writeRegistry struct {
+	mu     sync.Mutex
+	writes map[string]ownWrite
+}
+
+type ownWrite struct {
+	hash [sha256.Size]byte
+	time time.Time
+}
+
+// record remembers the write and forgets the writes that are older than ownWriteLifetime
+func (r *writeRegistry) record(path string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for writtenPath, write := range r.writes {
+		if now.Sub(write.time) > ownWriteLifetime {
+			delete(r.writes, writtenPath)
+		}
+	}
+	r.writes[absolutePath(path)] = ownWrite{hash: sha256.Sum256(data), time: now}
+}
+
+// saved returns true if the last write of this package to path had the hash
+func (r *writeRegistry) saved(path string, hash [sha256.Size]byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	write, exists := r.writes[absolutePath(path)]
+	return exists && write.hash == hash
+}
+
+func absolutePath(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return path
+}